@@ -0,0 +1,589 @@
+package main
+
+import (
+    "bytes"
+    "fmt"
+    "strconv"
+)
+
+// GlyphFont carries just enough of a PDF font resource to decode and
+// re-encode show-text operands: a code->rune table built from the font's
+// Encoding (Differences) or its ToUnicode CMap, plus per-code glyph
+// advance widths in 1000 glyph-space units so replacement text can be
+// padded to preserve layout.
+type GlyphFont struct {
+    CID          bool // composite font, codes are 2 bytes wide
+    Decode       map[uint32]rune
+    Encode       map[rune]uint32
+    Widths       map[uint32]float64
+    DefaultWidth float64
+}
+
+func (f *GlyphFont) codeWidth() int {
+    if f.CID {
+        return 2
+    }
+    return 1
+}
+
+// decodeOperand splits a show-text string operand into font codes and
+// decodes each to its Unicode rune, falling back to U+FFFD for codes the
+// font doesn't map (e.g. unmapped CIDs).
+func (f *GlyphFont) decodeOperand(operand []byte) (codes []uint32, runes []rune) {
+    step := f.codeWidth()
+    for i := 0; i+step <= len(operand); i += step {
+        var code uint32
+        for j := 0; j < step; j++ {
+            code = code<<8 | uint32(operand[i+j])
+        }
+        codes = append(codes, code)
+        if r, ok := f.Decode[code]; ok {
+            runes = append(runes, r)
+        } else {
+            runes = append(runes, '�')
+        }
+    }
+    return codes, runes
+}
+
+// encodeRunes maps a Unicode string back to font codes, substituting a
+// space code (or code 0 when the font has no space either) for runes the
+// font has no mapping for rather than failing the whole redaction. It returns both the codes (for width lookups) and
+// the encoded operand bytes.
+func (f *GlyphFont) encodeRunes(s string) (codes []uint32, encoded []byte) {
+    var buf bytes.Buffer
+    step := f.codeWidth()
+    spaceCode, haveSpace := f.Encode[' ']
+    for _, r := range s {
+        code, ok := f.Encode[r]
+        if !ok {
+            if haveSpace {
+                code = spaceCode
+            } else {
+                code = 0
+            }
+        }
+        codes = append(codes, code)
+        for j := step - 1; j >= 0; j-- {
+            buf.WriteByte(byte(code >> uint(8*j)))
+        }
+    }
+    return codes, buf.Bytes()
+}
+
+// packCodes re-serializes already-decoded font codes into a show-text
+// operand, used when stripping glyphs (blackbox/whiteout) rather than
+// substituting different text, so the original advance width is kept
+// exactly rather than recomputed.
+func (f *GlyphFont) packCodes(codes []uint32) []byte {
+    var buf bytes.Buffer
+    step := f.codeWidth()
+    for _, code := range codes {
+        for j := step - 1; j >= 0; j-- {
+            buf.WriteByte(byte(code >> uint(8*j)))
+        }
+    }
+    return buf.Bytes()
+}
+
+func (f *GlyphFont) widthOf(code uint32) float64 {
+    if w, ok := f.Widths[code]; ok {
+        return w
+    }
+    return f.DefaultWidth
+}
+
+// csObject is the decoded value of a content-stream operand: float64,
+// []byte (a literal or hex string with escapes already resolved), csName
+// (a /Name operand; operator names never appear here), csDict (an inline
+// << ... >> dict), csRaw (bytes kept verbatim), or []csObject for an
+// array (used by TJ).
+type csObject interface{}
+
+// csOp is one operator invocation together with its operands, in the
+// order they appeared in the content stream.
+type csOp struct {
+    Operands []csObject
+    Operator string
+}
+
+// parseContentStream tokenizes a page content stream into a flat list of
+// operations. It is intentionally permissive: operators and operand
+// types we don't care about are preserved as opaque csObjects so the
+// stream can be reassembled with every operator in place other than the
+// edits we make. Comments are dropped and whitespace, numbers and
+// strings are re-serialized, so the output is not byte-for-byte equal.
+func parseContentStream(data []byte) ([]csOp, error) {
+    var ops []csOp
+    var stack []csObject
+
+    i := 0
+    n := len(data)
+    for i < n {
+        c := data[i]
+        switch {
+        case isSpace(c):
+            i++
+        case c == '%':
+            for i < n && data[i] != '\n' && data[i] != '\r' {
+                i++
+            }
+        case c == '(':
+            s, next, err := scanLiteralString(data, i)
+            if err != nil {
+                return nil, err
+            }
+            stack = append(stack, s)
+            i = next
+        case c == '<' && i+1 < n && data[i+1] == '<':
+            // Inline dict, most commonly BDC marked-content properties
+            // (e.g. /Span << /ActualText (...) >>).
+            dict, next, err := scanDict(data, i)
+            if err != nil {
+                return nil, err
+            }
+            stack = append(stack, dict)
+            i = next
+        case c == '<':
+            s, next, err := scanHexString(data, i)
+            if err != nil {
+                return nil, err
+            }
+            stack = append(stack, s)
+            i = next
+        case c == '/':
+            name, next := scanName(data, i)
+            stack = append(stack, csName(name))
+            i = next
+        case c == '[':
+            arr, next, err := scanArray(data, i)
+            if err != nil {
+                return nil, err
+            }
+            stack = append(stack, arr)
+            i = next
+        case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+            num, next := scanNumber(data, i)
+            stack = append(stack, num)
+            i = next
+        default:
+            op, next := scanOperator(data, i)
+            if op == "" {
+                i++
+                continue
+            }
+            if op == "BI" {
+                // Inline image: keep everything from BI up to the matching
+                // EI verbatim as the raw operand of a single EI op.
+                skipTo, err := skipInlineImage(data, next)
+                if err != nil {
+                    return nil, err
+                }
+                ops = append(ops, csOp{Operands: []csObject{csRaw(data[i : skipTo-2])}, Operator: "EI"})
+                i = skipTo
+                stack = nil
+                continue
+            }
+            ops = append(ops, csOp{Operands: stack, Operator: op})
+            stack = nil
+            i = next
+        }
+    }
+    return ops, nil
+}
+
+// csName is a /Name operand.
+type csName string
+
+func isSpace(c byte) bool {
+    return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isDelim(c byte) bool {
+    switch c {
+    case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+        return true
+    }
+    return false
+}
+
+func scanLiteralString(data []byte, start int) ([]byte, int, error) {
+    i := start + 1
+    depth := 1
+    var out []byte
+    for i < len(data) {
+        c := data[i]
+        switch c {
+        case '\\':
+            if i+1 >= len(data) {
+                return nil, 0, fmt.Errorf("contentstream: unterminated escape in literal string")
+            }
+            esc := data[i+1]
+            switch esc {
+            case 'n':
+                out = append(out, '\n')
+                i += 2
+            case 'r':
+                out = append(out, '\r')
+                i += 2
+            case 't':
+                out = append(out, '\t')
+                i += 2
+            case 'b':
+                out = append(out, '\b')
+                i += 2
+            case 'f':
+                out = append(out, '\f')
+                i += 2
+            case '(', ')', '\\':
+                out = append(out, esc)
+                i += 2
+            case '\r':
+                i += 2
+                if i < len(data) && data[i] == '\n' {
+                    i++
+                }
+            case '\n':
+                i += 2
+            default:
+                if esc >= '0' && esc <= '7' {
+                    j := i + 1
+                    val := 0
+                    for k := 0; k < 3 && j < len(data) && data[j] >= '0' && data[j] <= '7'; k++ {
+                        val = val*8 + int(data[j]-'0')
+                        j++
+                    }
+                    out = append(out, byte(val))
+                    i = j
+                } else {
+                    out = append(out, esc)
+                    i += 2
+                }
+            }
+        case '(':
+            depth++
+            out = append(out, c)
+            i++
+        case ')':
+            depth--
+            i++
+            if depth == 0 {
+                return out, i, nil
+            }
+            out = append(out, c)
+        default:
+            out = append(out, c)
+            i++
+        }
+    }
+    return nil, 0, fmt.Errorf("contentstream: unterminated literal string")
+}
+
+func scanHexString(data []byte, start int) ([]byte, int, error) {
+    i := start + 1
+    var hex []byte
+    for i < len(data) && data[i] != '>' {
+        if !isSpace(data[i]) {
+            hex = append(hex, data[i])
+        }
+        i++
+    }
+    if i >= len(data) {
+        return nil, 0, fmt.Errorf("contentstream: unterminated hex string")
+    }
+    i++ // consume '>'
+    if len(hex)%2 == 1 {
+        hex = append(hex, '0')
+    }
+    out := make([]byte, len(hex)/2)
+    for j := 0; j < len(out); j++ {
+        b, err := strconv.ParseUint(string(hex[2*j:2*j+2]), 16, 8)
+        if err != nil {
+            return nil, 0, fmt.Errorf("contentstream: invalid hex string: %v", err)
+        }
+        out[j] = byte(b)
+    }
+    return out, i, nil
+}
+
+func scanName(data []byte, start int) (string, int) {
+    i := start + 1
+    var out []byte
+    for i < len(data) && !isSpace(data[i]) && !isDelim(data[i]) {
+        if data[i] == '#' && i+2 < len(data) {
+            if b, err := strconv.ParseUint(string(data[i+1:i+3]), 16, 8); err == nil {
+                out = append(out, byte(b))
+                i += 3
+                continue
+            }
+        }
+        out = append(out, data[i])
+        i++
+    }
+    return string(out), i
+}
+
+func scanNumber(data []byte, start int) (float64, int) {
+    i := start
+    for i < len(data) && (data[i] == '-' || data[i] == '+' || data[i] == '.' || (data[i] >= '0' && data[i] <= '9')) {
+        i++
+    }
+    v, _ := strconv.ParseFloat(string(data[start:i]), 64)
+    return v, i
+}
+
+func scanOperator(data []byte, start int) (string, int) {
+    i := start
+    for i < len(data) && !isSpace(data[i]) && !isDelim(data[i]) {
+        i++
+    }
+    return string(data[start:i]), i
+}
+
+func scanArray(data []byte, start int) ([]csObject, int, error) {
+    i := start + 1
+    var arr []csObject
+    for i < len(data) && data[i] != ']' {
+        switch {
+        case isSpace(data[i]):
+            i++
+        case data[i] == '(':
+            s, next, err := scanLiteralString(data, i)
+            if err != nil {
+                return nil, 0, err
+            }
+            arr = append(arr, s)
+            i = next
+        case data[i] == '<':
+            s, next, err := scanHexString(data, i)
+            if err != nil {
+                return nil, 0, err
+            }
+            arr = append(arr, s)
+            i = next
+        case data[i] == '-' || data[i] == '+' || data[i] == '.' || (data[i] >= '0' && data[i] <= '9'):
+            num, next := scanNumber(data, i)
+            arr = append(arr, num)
+            i = next
+        default:
+            return nil, 0, fmt.Errorf("contentstream: unexpected byte %q in array", data[i])
+        }
+    }
+    if i >= len(data) {
+        return nil, 0, fmt.Errorf("contentstream: unterminated array")
+    }
+    return arr, i + 1, nil
+}
+
+// csDictEntry is one key/value pair of an inline marked-content
+// properties dict, kept in source order so round-tripping doesn't
+// reorder entries a reader/diff would notice.
+type csDictEntry struct {
+    Key string
+    Val csObject
+}
+
+// csDict is an inline << ... >> operand, e.g. BDC properties.
+type csDict []csDictEntry
+
+// csRaw is a nested structure inside a dict (an array or nested dict)
+// that we don't need to inspect; its original bytes are preserved
+// verbatim so we can round-trip it without fully modeling it.
+type csRaw []byte
+
+func (d csDict) get(key string) (csObject, bool) {
+    for _, e := range d {
+        if e.Key == key {
+            return e.Val, true
+        }
+    }
+    return nil, false
+}
+
+func (d csDict) set(key string, val csObject) csDict {
+    out := make(csDict, len(d))
+    copy(out, d)
+    for i := range out {
+        if out[i].Key == key {
+            out[i].Val = val
+            return out
+        }
+    }
+    return append(out, csDictEntry{Key: key, Val: val})
+}
+
+// scanDict parses a balanced << ... >> dict into a csDict. Scalar values
+// (names, strings, numbers) are decoded so callers can inspect and
+// rewrite them (e.g. /ActualText); arrays and nested dicts are kept as
+// opaque csRaw so they still round-trip byte-for-byte.
+func scanDict(data []byte, start int) (csDict, int, error) {
+    i := start + 2 // skip "<<"
+    var dict csDict
+    for i < len(data) {
+        for i < len(data) && isSpace(data[i]) {
+            i++
+        }
+        if i+1 < len(data) && data[i] == '>' && data[i+1] == '>' {
+            return dict, i + 2, nil
+        }
+        if i >= len(data) || data[i] != '/' {
+            return nil, 0, fmt.Errorf("contentstream: expected dict key at offset %d", i)
+        }
+        key, next := scanName(data, i)
+        i = next
+        for i < len(data) && isSpace(data[i]) {
+            i++
+        }
+        val, next, err := scanDictValue(data, i)
+        if err != nil {
+            return nil, 0, err
+        }
+        dict = append(dict, csDictEntry{Key: key, Val: val})
+        i = next
+    }
+    return nil, 0, fmt.Errorf("contentstream: unterminated inline dict")
+}
+
+func scanDictValue(data []byte, i int) (csObject, int, error) {
+    if i >= len(data) {
+        return nil, 0, fmt.Errorf("contentstream: unterminated dict value")
+    }
+    switch {
+    case data[i] == '(':
+        return scanLiteralString(data, i)
+    case data[i] == '<' && i+1 < len(data) && data[i+1] == '<':
+        return scanRaw(data, i)
+    case data[i] == '<':
+        return scanHexString(data, i)
+    case data[i] == '/':
+        name, next := scanName(data, i)
+        return csName(name), next, nil
+    case data[i] == '[':
+        return scanRaw(data, i)
+    case data[i] == '-' || data[i] == '+' || data[i] == '.' || (data[i] >= '0' && data[i] <= '9'):
+        num, next := scanNumber(data, i)
+        return num, next, nil
+    default:
+        // Keywords like `true`/`false`/`null`.
+        op, next := scanOperator(data, i)
+        return csName(op), next, nil
+    }
+}
+
+// scanRaw captures the verbatim bytes of a balanced <<...>> or [...]
+// structure without interpreting its contents.
+func scanRaw(data []byte, start int) (csRaw, int, error) {
+    open, close := byte('['), byte(']')
+    nested := data[start] == '<'
+    i := start
+    depth := 0
+    for i < len(data) {
+        if nested {
+            if i+1 < len(data) && data[i] == '<' && data[i+1] == '<' {
+                depth++
+                i += 2
+                continue
+            }
+            if i+1 < len(data) && data[i] == '>' && data[i+1] == '>' {
+                depth--
+                i += 2
+                if depth == 0 {
+                    return csRaw(data[start:i]), i, nil
+                }
+                continue
+            }
+        } else {
+            if data[i] == open {
+                depth++
+            } else if data[i] == close {
+                depth--
+                if depth == 0 {
+                    i++
+                    return csRaw(data[start:i]), i, nil
+                }
+            }
+        }
+        i++
+    }
+    return nil, 0, fmt.Errorf("contentstream: unterminated nested structure")
+}
+
+func skipInlineImage(data []byte, start int) (int, error) {
+    idx := bytes.Index(data[start:], []byte("EI"))
+    if idx == -1 {
+        return 0, fmt.Errorf("contentstream: unterminated inline image (no EI)")
+    }
+    return start + idx + 2, nil
+}
+
+// writeContentStream serializes operations back into content-stream
+// bytes. Operators are re-emitted exactly as pdfcpu would expect to read
+// them back: operands first, operator last, separated by single spaces.
+func writeContentStream(ops []csOp) []byte {
+    var buf bytes.Buffer
+    for _, op := range ops {
+        for _, operand := range op.Operands {
+            writeCSObject(&buf, operand)
+            buf.WriteByte(' ')
+        }
+        buf.WriteString(op.Operator)
+        buf.WriteByte('\n')
+    }
+    return buf.Bytes()
+}
+
+func writeCSObject(buf *bytes.Buffer, obj csObject) {
+    switch v := obj.(type) {
+    case float64:
+        buf.WriteString(formatNumber(v))
+    case csName:
+        buf.WriteByte('/')
+        buf.WriteString(string(v))
+    case []byte:
+        writeLiteralString(buf, v)
+    case []csObject:
+        buf.WriteByte('[')
+        for i, e := range v {
+            if i > 0 {
+                buf.WriteByte(' ')
+            }
+            writeCSObject(buf, e)
+        }
+        buf.WriteByte(']')
+    case csDict:
+        buf.WriteString("<<")
+        for _, e := range v {
+            buf.WriteByte('/')
+            buf.WriteString(e.Key)
+            buf.WriteByte(' ')
+            writeCSObject(buf, e.Val)
+            buf.WriteByte(' ')
+        }
+        buf.WriteString(">>")
+    case csRaw:
+        buf.Write(v)
+    }
+}
+
+func formatNumber(v float64) string {
+    s := strconv.FormatFloat(v, 'f', -1, 64)
+    return s
+}
+
+func writeLiteralString(buf *bytes.Buffer, s []byte) {
+    buf.WriteByte('(')
+    for _, b := range s {
+        switch b {
+        case '(', ')', '\\':
+            buf.WriteByte('\\')
+            buf.WriteByte(b)
+        case '\n':
+            buf.WriteString("\\n")
+        case '\r':
+            buf.WriteString("\\r")
+        default:
+            buf.WriteByte(b)
+        }
+    }
+    buf.WriteByte(')')
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+    "strings"
+    "testing"
+)
+
+func asciiFont() *GlyphFont {
+    f := &GlyphFont{
+        Decode:       map[uint32]rune{},
+        Encode:       map[rune]uint32{},
+        Widths:       map[uint32]float64{},
+        DefaultWidth: 500,
+    }
+    for c := 32; c <= 126; c++ {
+        f.Decode[uint32(c)] = rune(c)
+        f.Encode[rune(c)] = uint32(c)
+        f.Widths[uint32(c)] = 500
+    }
+    return f
+}
+
+func winAnsiFont() *GlyphFont {
+    f := asciiFont()
+    f.Decode[0x93] = '“'
+    f.Encode['“'] = 0x93
+    f.Widths[0x93] = 333
+    f.Decode[0x94] = '”'
+    f.Encode['”'] = 0x94
+    f.Widths[0x94] = 333
+    return f
+}
+
+// cidFont simulates an Identity-H CID font where code == CID and a
+// ToUnicode CMap maps each CID to its ASCII rune, mirroring how a
+// subsetted embedded font is typically encoded.
+func cidFont() *GlyphFont {
+    f := &GlyphFont{
+        CID:          true,
+        Decode:       map[uint32]rune{},
+        Encode:       map[rune]uint32{},
+        Widths:       map[uint32]float64{},
+        DefaultWidth: 1000,
+    }
+    for c := 32; c <= 126; c++ {
+        cid := uint32(c + 100) // CIDs need not match ASCII codes
+        f.Decode[cid] = rune(c)
+        f.Encode[rune(c)] = cid
+        f.Widths[cid] = 600
+    }
+    return f
+}
+
+func ssnRule() []ReplacementRule {
+    return []ReplacementRule{
+        {Find: `\d{3}-\d{2}-\d{4}`, Replace: "[SSN]", Regex: true},
+    }
+}
+
+func TestRedactContentStream_ASCII_SingleTj(t *testing.T) {
+    stream := []byte("BT /F1 12 Tf (SSN: 123-45-6789) Tj ET")
+    fonts := map[string]*GlyphFont{"F1": asciiFont()}
+
+    out, n, err := redactContentStream(stream, fonts, ssnRule())
+    if err != nil {
+        t.Fatalf("redactContentStream: %v", err)
+    }
+    if n != 1 {
+        t.Fatalf("expected 1 match, got %d", n)
+    }
+    if strings.Contains(string(out), "123-45-6789") {
+        t.Fatalf("SSN still present in output: %s", out)
+    }
+    if !strings.Contains(string(out), "SSN: [SSN]") {
+        t.Fatalf("expected redacted text in output, got: %s", out)
+    }
+}
+
+func TestRedactContentStream_WinAnsiSmartQuotes(t *testing.T) {
+    // \223 and \224 are octal for 0x93/0x94 (WinAnsi curly quotes).
+    stream := []byte("BT /F1 12 Tf (\\223123-45-6789\\224) Tj ET")
+    fonts := map[string]*GlyphFont{"F1": winAnsiFont()}
+
+    out, n, err := redactContentStream(stream, fonts, ssnRule())
+    if err != nil {
+        t.Fatalf("redactContentStream: %v", err)
+    }
+    if n != 1 {
+        t.Fatalf("expected 1 match, got %d", n)
+    }
+    if strings.Contains(string(out), "123-45-6789") {
+        t.Fatalf("SSN still present in output: %s", out)
+    }
+}
+
+func TestRedactContentStream_CIDFont_TJArray(t *testing.T) {
+    f := cidFont()
+    encode := func(s string) []byte {
+        _, b := f.encodeRunes(s)
+        return b
+    }
+
+    var stream strings.Builder
+    stream.WriteString("BT /F1 12 Tf [<")
+    for _, b := range encode("SSN: 123-45-") {
+        stream.WriteString(hexByte(b))
+    }
+    stream.WriteString("> -20 <")
+    for _, b := range encode("6789") {
+        stream.WriteString(hexByte(b))
+    }
+    stream.WriteString(">] TJ ET")
+
+    fonts := map[string]*GlyphFont{"F1": f}
+    out, n, err := redactContentStream([]byte(stream.String()), fonts, ssnRule())
+    if err != nil {
+        t.Fatalf("redactContentStream: %v", err)
+    }
+    if n != 1 {
+        t.Fatalf("expected 1 match, got %d", n)
+    }
+
+    // Decode the rewritten TJ string back to confirm the SSN is gone.
+    ops, err := parseContentStream(out)
+    if err != nil {
+        t.Fatalf("parseContentStream(out): %v", err)
+    }
+    var decoded string
+    for _, op := range ops {
+        if op.Operator != "TJ" {
+            continue
+        }
+        arr, ok := op.Operands[0].([]csObject)
+        if !ok {
+            continue
+        }
+        for _, e := range arr {
+            if s, ok := e.([]byte); ok {
+                _, runes := f.decodeOperand(s)
+                decoded += string(runes)
+            }
+        }
+    }
+    if strings.Contains(decoded, "123-45-6789") {
+        t.Fatalf("SSN still present in decoded output: %q", decoded)
+    }
+    if !strings.Contains(decoded, "[SSN]") {
+        t.Fatalf("expected [SSN] token in decoded output: %q", decoded)
+    }
+}
+
+func TestParseAndWriteContentStream_Roundtrip(t *testing.T) {
+    stream := []byte("q 1 0 0 1 72 700 cm BT /F1 12 Tf (hello) Tj ET Q")
+    ops, err := parseContentStream(stream)
+    if err != nil {
+        t.Fatalf("parseContentStream: %v", err)
+    }
+    if len(ops) == 0 {
+        t.Fatal("expected at least one operation")
+    }
+    out := writeContentStream(ops)
+    if !strings.Contains(string(out), "(hello) Tj") {
+        t.Fatalf("expected operand/operator order preserved, got: %s", out)
+    }
+}
+
+func hexByte(b byte) string {
+    const hexdigits = "0123456789ABCDEF"
+    return string([]byte{hexdigits[b>>4], hexdigits[b&0xF]})
+}
@@ -0,0 +1,62 @@
+package main
+
+// matrix6 is a PDF transformation matrix [a b c d e f], the row-major
+// representation used throughout the PDF spec for Tm/cm operands:
+//
+//	| a b 0 |
+//	| c d 0 |
+//	| e f 1 |
+type matrix6 struct {
+    a, b, c, d, e, f float64
+}
+
+func identityMatrix() matrix6 {
+    return matrix6{a: 1, d: 1}
+}
+
+func translateMatrix(tx, ty float64) matrix6 {
+    return matrix6{a: 1, d: 1, e: tx, f: ty}
+}
+
+// mulMatrix composes two matrices such that applying m1 then m2 is
+// equivalent to applying mulMatrix(m1, m2), matching the PDF spec's "new = m × old"
+// convention for Td/cm concatenation.
+func mulMatrix(m1, m2 matrix6) matrix6 {
+    return matrix6{
+        a: m1.a*m2.a + m1.b*m2.c,
+        b: m1.a*m2.b + m1.b*m2.d,
+        c: m1.c*m2.a + m1.d*m2.c,
+        d: m1.c*m2.b + m1.d*m2.d,
+        e: m1.e*m2.a + m1.f*m2.c + m2.e,
+        f: m1.e*m2.b + m1.f*m2.d + m2.f,
+    }
+}
+
+func (m matrix6) apply(x, y float64) (float64, float64) {
+    return m.a*x + m.c*y + m.e, m.b*x + m.d*y + m.f
+}
+
+// axisAlignedBBox transforms the corners of the (x0,y0)-(x1,y1) rect
+// through m and returns the axis-aligned bounding box of the result, so
+// callers can emit a plain `re` even when m carries rotation or skew.
+func axisAlignedBBox(m matrix6, x0, y0, x1, y1 float64) (rx, ry, rw, rh float64) {
+    corners := [4][2]float64{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}}
+    minX, minY := m.apply(corners[0][0], corners[0][1])
+    maxX, maxY := minX, minY
+    for _, c := range corners[1:] {
+        px, py := m.apply(c[0], c[1])
+        if px < minX {
+            minX = px
+        }
+        if px > maxX {
+            maxX = px
+        }
+        if py < minY {
+            minY = py
+        }
+        if py > maxY {
+            maxY = py
+        }
+    }
+    return minX, minY, maxX - minX, maxY - minY
+}
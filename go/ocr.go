@@ -0,0 +1,449 @@
+package main
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "image"
+    _ "image/jpeg"
+    "image/png"
+    "io/ioutil"
+    "os"
+    "os/exec"
+    "regexp"
+    "strconv"
+    "strings"
+
+    "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+    "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// OCRWord is one word an OCREngine recognized, with its bounding box in
+// the pixel space of the image that was passed to Recognize.
+type OCRWord struct {
+    Text string
+    BBox [4]float64 // x0, y0, x1, y1, top-left origin, pixels
+}
+
+// OCREngine recognizes text in a rasterized page image. Registered
+// engines are looked up by name via RegisterOCREngine/ocrEngineByName so
+// users can plug in their own (a cloud OCR API, a different local
+// binary) without touching this package.
+type OCREngine interface {
+    Recognize(img image.Image) ([]OCRWord, error)
+}
+
+// noopOCREngine recognizes nothing. It's the safe default for
+// environments without an OCR binary installed, and a convenient stand-in
+// in tests.
+type noopOCREngine struct{}
+
+func (noopOCREngine) Recognize(img image.Image) ([]OCRWord, error) {
+    return nil, nil
+}
+
+// tesseractEngine shells out to the `tesseract` CLI, the engine used
+// when OCR mode is enabled and no engine name is given.
+type tesseractEngine struct{}
+
+func (tesseractEngine) Recognize(img image.Image) ([]OCRWord, error) {
+    tmp, err := ioutil.TempFile("", "redactor-ocr-*.png")
+    if err != nil {
+        return nil, fmt.Errorf("tesseract: creating temp image: %v", err)
+    }
+    defer os.Remove(tmp.Name())
+
+    if err := png.Encode(tmp, img); err != nil {
+        tmp.Close()
+        return nil, fmt.Errorf("tesseract: encoding temp image: %v", err)
+    }
+    if err := tmp.Close(); err != nil {
+        return nil, err
+    }
+
+    out, err := exec.Command("tesseract", tmp.Name(), "stdout", "tsv").Output()
+    if err != nil {
+        return nil, fmt.Errorf("tesseract: %v", err)
+    }
+    return parseTesseractTSV(out), nil
+}
+
+// parseTesseractTSV reads the `tesseract ... tsv` output format: a
+// header row of column names, then one row per recognized word/line/
+// block/etc, with a "level" column distinguishing them. We only keep
+// word-level rows with non-empty text.
+func parseTesseractTSV(out []byte) []OCRWord {
+    lines := strings.Split(string(out), "\n")
+    if len(lines) == 0 {
+        return nil
+    }
+    col := map[string]int{}
+    for i, h := range strings.Split(lines[0], "\t") {
+        col[h] = i
+    }
+
+    var words []OCRWord
+    for _, line := range lines[1:] {
+        if line == "" {
+            continue
+        }
+        fields := strings.Split(line, "\t")
+        if idx, ok := col["level"]; ok && idx < len(fields) && fields[idx] != "5" {
+            continue // level 5 == word; skip block/paragraph/line/page summary rows
+        }
+        text := fieldAt(fields, col, "text")
+        if strings.TrimSpace(text) == "" {
+            continue
+        }
+        left, _ := strconv.ParseFloat(fieldAt(fields, col, "left"), 64)
+        top, _ := strconv.ParseFloat(fieldAt(fields, col, "top"), 64)
+        width, _ := strconv.ParseFloat(fieldAt(fields, col, "width"), 64)
+        height, _ := strconv.ParseFloat(fieldAt(fields, col, "height"), 64)
+        words = append(words, OCRWord{
+            Text: strings.TrimSpace(text),
+            BBox: [4]float64{left, top, left + width, top + height},
+        })
+    }
+    return words
+}
+
+func fieldAt(fields []string, col map[string]int, name string) string {
+    idx, ok := col[name]
+    if !ok || idx >= len(fields) {
+        return ""
+    }
+    return fields[idx]
+}
+
+// ocrEngines is the engine registry; RegisterOCREngine lets callers add
+// to it (or override "tesseract"/"noop") before running OCR mode.
+var ocrEngines = map[string]OCREngine{
+    "noop":      noopOCREngine{},
+    "tesseract": tesseractEngine{},
+}
+
+// RegisterOCREngine makes engine available under name to OCRConfig.Engine
+// and the `--ocr-engine` flag.
+func RegisterOCREngine(name string, engine OCREngine) {
+    ocrEngines[name] = engine
+}
+
+func ocrEngineByName(name string) (OCREngine, error) {
+    if name == "" {
+        name = "tesseract"
+    }
+    engine, ok := ocrEngines[name]
+    if !ok {
+        return nil, fmt.Errorf("unknown OCR engine %q", name)
+    }
+    return engine, nil
+}
+
+// OCRConfig controls the `--ocr` fallback path.
+type OCRConfig struct {
+    Enabled   bool    `json:"enabled"`
+    Engine    string  `json:"engine"`    // "tesseract" (default) or "noop", or a name registered via RegisterOCREngine
+    Threshold float64 `json:"threshold"` // chars per square inch of extracted text below which a page is treated as scanned
+}
+
+// redactScannedPages OCRs any page whose extracted text density falls
+// below cfg.Threshold, matches the configured rules against recognized
+// words, and overlays opaque rectangles at the matched word positions.
+// It returns the number of words redacted this way.
+func (r *PDFRedactor) redactScannedPages(ctx *model.Context, cfg OCRConfig) (int, error) {
+    if !cfg.Enabled {
+        return 0, nil
+    }
+    engine, err := ocrEngineByName(cfg.Engine)
+    if err != nil {
+        return 0, err
+    }
+    threshold := cfg.Threshold
+    if threshold <= 0 {
+        threshold = 1 // one extracted character per square inch is already a very sparse page
+    }
+
+    xRefTable := ctx.XRefTable
+    totalWords := 0
+
+    for pageNr := 1; pageNr <= xRefTable.PageCount; pageNr++ {
+        pageDict, _, _, err := xRefTable.PageDict(pageNr, false)
+        if err != nil || pageDict == nil {
+            continue
+        }
+
+        density, err := pageTextDensity(xRefTable, pageDict)
+        if err != nil || density >= threshold {
+            continue
+        }
+
+        img, err := extractPageImage(xRefTable, pageDict)
+        if err != nil {
+            var unsupported *unsupportedImageFilterError
+            if errors.As(err, &unsupported) {
+                fmt.Printf("OCR: page %d is a scanned image this tool can't decode (%v); its text was NOT redacted\n", pageNr, err)
+            }
+            continue // page has no extractable/decodable full-page image; nothing OCR can do here
+        }
+
+        words, err := engine.Recognize(img)
+        if err != nil {
+            return totalWords, fmt.Errorf("page %d OCR: %v", pageNr, err)
+        }
+
+        mediaBox, err := mediaBoxFor(xRefTable, pageDict)
+        if err != nil {
+            continue
+        }
+
+        var rects []rect
+        for _, word := range words {
+            for _, rule := range r.replacements {
+                if !matchesRule(word.Text, rule) {
+                    continue
+                }
+                mode := normalizeModeForOCR(rule.Mode)
+                rects = append(rects, ocrWordToPageRect(word, img, mediaBox, mode))
+                totalWords++
+                break
+            }
+        }
+        if len(rects) == 0 {
+            continue
+        }
+        if err := appendRectsToPage(xRefTable, pageDict, rects); err != nil {
+            return totalWords, fmt.Errorf("page %d: %v", pageNr, err)
+        }
+    }
+
+    return totalWords, nil
+}
+
+// normalizeModeForOCR defaults to blackbox: OCR words have no
+// re-encodable glyphs to substitute text into, so "replace" mode doesn't
+// apply the way it does for real text.
+func normalizeModeForOCR(mode string) string {
+    if mode == ModeWhiteout {
+        return ModeWhiteout
+    }
+    return ModeBlackbox
+}
+
+// matchesRule reports whether rule would redact text somewhere in word,
+// honoring CaseInsensitive/Validator the same way ProcessText does.
+func matchesRule(text string, rule ReplacementRule) bool {
+    if rule.Regex {
+        pattern := rule.Find
+        if rule.CaseInsensitive {
+            pattern = "(?i)" + pattern
+        }
+        for _, loc := range regexp.MustCompile(pattern).FindAllStringIndex(text, -1) {
+            if rule.Validator == nil || rule.Validator(text[loc[0]:loc[1]]) {
+                return true
+            }
+        }
+        return false
+    }
+
+    hay, needle := text, rule.Find
+    if rule.CaseInsensitive {
+        hay = strings.ToLower(hay)
+        needle = strings.ToLower(needle)
+    }
+    return needle != "" && strings.Contains(hay, needle)
+}
+
+// pageTextDensity returns a page's extracted character count per square
+// inch of its MediaBox, the signal used to decide a page is a scan.
+func pageTextDensity(xRefTable *model.XRefTable, pageDict types.Dict) (float64, error) {
+    fonts, err := pageFonts(xRefTable, pageDict)
+    if err != nil {
+        return 0, err
+    }
+    raw, err := pageContentBytes(xRefTable, pageDict)
+    if err != nil {
+        return 0, err
+    }
+    ops, err := parseContentStream(raw)
+    if err != nil {
+        return 0, err
+    }
+
+    var curFont *GlyphFont
+    chars := 0
+    for _, op := range ops {
+        switch op.Operator {
+        case "Tf":
+            if len(op.Operands) >= 1 {
+                if name, ok := op.Operands[0].(csName); ok {
+                    curFont = fonts[string(name)]
+                }
+            }
+        case "Tj", "'", "\"":
+            if curFont == nil || len(op.Operands) == 0 {
+                continue
+            }
+            if s, ok := op.Operands[len(op.Operands)-1].([]byte); ok {
+                _, runes := curFont.decodeOperand(s)
+                chars += len(runes)
+            }
+        case "TJ":
+            if curFont == nil || len(op.Operands) == 0 {
+                continue
+            }
+            if arr, ok := op.Operands[0].([]csObject); ok {
+                for _, e := range arr {
+                    if s, ok := e.([]byte); ok {
+                        _, runes := curFont.decodeOperand(s)
+                        chars += len(runes)
+                    }
+                }
+            }
+        }
+    }
+
+    mediaBox, err := mediaBoxFor(xRefTable, pageDict)
+    if err != nil {
+        return 0, err
+    }
+    areaSqIn := ((mediaBox.UR.X - mediaBox.LL.X) / 72) * ((mediaBox.UR.Y - mediaBox.LL.Y) / 72)
+    if areaSqIn <= 0 {
+        return 0, fmt.Errorf("degenerate MediaBox")
+    }
+    return float64(chars) / areaSqIn, nil
+}
+
+// mediaBoxFor reads a page's own /MediaBox. It doesn't walk up the page
+// tree for an inherited box (most real-world scanned PDFs set it on the
+// page itself); a US Letter default covers the rare inherited case.
+func mediaBoxFor(xRefTable *model.XRefTable, pageDict types.Dict) (*types.Rectangle, error) {
+    arr, err := xRefTable.DereferenceArray(pageDict["MediaBox"])
+    if err != nil || len(arr) != 4 {
+        return types.NewRectangle(0, 0, 612, 792), nil
+    }
+    llx, _ := asFloat(arr[0])
+    lly, _ := asFloat(arr[1])
+    urx, _ := asFloat(arr[2])
+    ury, _ := asFloat(arr[3])
+    return types.NewRectangle(llx, lly, urx, ury), nil
+}
+
+// unsupportedImageFilterError reports that a page's scanned image is
+// encoded with a filter this package can't decode: Go's stdlib image
+// package only ships JPEG/PNG/GIF decoders, while scanned black-and-white
+// documents are dominated in practice by CCITTFaxDecode (G3/G4 fax), with
+// JBIG2Decode and JPXDecode also common. Surfacing this distinctly lets
+// the caller warn the user instead of silently skipping the page.
+type unsupportedImageFilterError struct {
+    filter string
+}
+
+func (e *unsupportedImageFilterError) Error() string {
+    return fmt.Sprintf("image uses unsupported filter %q (only JPEG/PNG-compatible filters are supported)", e.filter)
+}
+
+var unsupportedImageFilters = map[string]bool{
+    "CCITTFaxDecode": true,
+    "JBIG2Decode":    true,
+    "JPXDecode":      true,
+}
+
+// streamFilterNames returns a stream dict's /Filter entry, whether it's a
+// single Name or an Array of them.
+func streamFilterNames(sd *types.StreamDict) []string {
+    switch f := sd.Dict["Filter"].(type) {
+    case types.Name:
+        return []string{string(f)}
+    case types.Array:
+        var names []string
+        for _, e := range f {
+            if n, ok := e.(types.Name); ok {
+                names = append(names, string(n))
+            }
+        }
+        return names
+    default:
+        return nil
+    }
+}
+
+// extractPageImage finds the largest Image XObject referenced by a
+// page's resources. Scanned PDFs overwhelmingly consist of a single
+// full-page image per page, so the largest one is almost always it.
+func extractPageImage(xRefTable *model.XRefTable, pageDict types.Dict) (image.Image, error) {
+    resDict, err := xRefTable.DereferenceDict(pageDict["Resources"])
+    if err != nil || resDict == nil {
+        return nil, fmt.Errorf("page has no /Resources")
+    }
+    xObjDict, err := xRefTable.DereferenceDict(resDict["XObject"])
+    if err != nil || xObjDict == nil {
+        return nil, fmt.Errorf("page has no XObjects")
+    }
+
+    var best *types.StreamDict
+    for _, ref := range xObjDict {
+        sd, _, err := xRefTable.DereferenceStreamDict(ref)
+        if err != nil || sd == nil {
+            continue
+        }
+        if subtype := sd.Dict.NameEntry("Subtype"); subtype == nil || *subtype != "Image" {
+            continue
+        }
+        if best == nil || len(sd.Raw) > len(best.Raw) {
+            best = sd
+        }
+    }
+    if best == nil {
+        return nil, fmt.Errorf("no image XObject found")
+    }
+
+    for _, filter := range streamFilterNames(best) {
+        if unsupportedImageFilters[filter] {
+            return nil, &unsupportedImageFilterError{filter: filter}
+        }
+    }
+
+    img, _, err := image.Decode(bytes.NewReader(best.Raw))
+    if err != nil {
+        if filters := streamFilterNames(best); len(filters) > 0 {
+            return nil, &unsupportedImageFilterError{filter: filters[len(filters)-1]}
+        }
+        return nil, fmt.Errorf("decoding page image: %v", err)
+    }
+    return img, nil
+}
+
+// ocrWordToPageRect converts a word's pixel bounding box (top-left
+// origin) into a PDF user-space rect, using the image's pixel dimensions
+// against the page's MediaBox under the common assumption that the
+// scanned image covers the full page.
+func ocrWordToPageRect(word OCRWord, img image.Image, mediaBox *types.Rectangle, mode string) rect {
+    bounds := img.Bounds()
+    w := float64(bounds.Dx())
+    h := float64(bounds.Dy())
+    mw := mediaBox.UR.X - mediaBox.LL.X
+    mh := mediaBox.UR.Y - mediaBox.LL.Y
+
+    x0 := mediaBox.LL.X + word.BBox[0]/w*mw
+    x1 := mediaBox.LL.X + word.BBox[2]/w*mw
+    // Image rows run top-down; PDF user space runs bottom-up.
+    y1 := mediaBox.UR.Y - word.BBox[1]/h*mh
+    y0 := mediaBox.UR.Y - word.BBox[3]/h*mh
+
+    return rect{x: x0, y: y0, w: x1 - x0, h: y1 - y0, mode: mode}
+}
+
+// appendRectsToPage draws rects on top of a page's existing content by
+// appending fill ops after the original content stream, leaving the
+// scanned image itself untouched underneath.
+func appendRectsToPage(xRefTable *model.XRefTable, pageDict types.Dict, rects []rect) error {
+    existing, err := pageContentBytes(xRefTable, pageDict)
+    if err != nil {
+        return err
+    }
+    var extra []csOp
+    for _, rc := range rects {
+        extra = append(extra, rectOps(rc)...)
+    }
+    return setPageContentBytes(xRefTable, pageDict, append(existing, writeContentStream(extra)...))
+}
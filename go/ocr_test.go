@@ -0,0 +1,164 @@
+package main
+
+import (
+    "errors"
+    "image"
+    "testing"
+
+    "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+func TestMatchesRule(t *testing.T) {
+    rule := ReplacementRule{Find: "secret", Regex: false}
+    if !matchesRule("the secret word", rule) {
+        t.Error("expected substring match")
+    }
+    if matchesRule("nothing here", rule) {
+        t.Error("expected no match")
+    }
+
+    caseInsensitive := ReplacementRule{Find: "secret", CaseInsensitive: true}
+    if !matchesRule("SECRET", caseInsensitive) {
+        t.Error("expected case-insensitive match")
+    }
+
+    regexRule := ReplacementRule{Find: `\d{3}-\d{2}-\d{4}`, Regex: true}
+    if !matchesRule("SSN 123-45-6789 on file", regexRule) {
+        t.Error("expected regex match")
+    }
+    if matchesRule("no ssn here", regexRule) {
+        t.Error("expected no regex match")
+    }
+}
+
+func TestMatchesRule_ValidatorGatesRegexMatch(t *testing.T) {
+    rule := ReplacementRule{
+        Find:      `\d{16}`,
+        Regex:     true,
+        Validator: func(match string) bool { return match == "4111111111111111" },
+    }
+    if !matchesRule("card 4111111111111111", rule) {
+        t.Error("expected validator to accept a valid card number")
+    }
+    if matchesRule("card 1234567812345678", rule) {
+        t.Error("expected validator to reject an invalid card number")
+    }
+}
+
+func TestParseTesseractTSV(t *testing.T) {
+    tsv := "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+        "1\t1\t0\t0\t0\t0\t0\t0\t200\t300\t-1\t\n" +
+        "5\t1\t1\t1\t1\t1\t10\t20\t50\t15\t95.0\tHello\n" +
+        "5\t1\t1\t1\t1\t2\t70\t20\t40\t15\t92.0\tWorld\n"
+
+    words := parseTesseractTSV([]byte(tsv))
+    if len(words) != 2 {
+        t.Fatalf("expected 2 words, got %d", len(words))
+    }
+    if words[0].Text != "Hello" || words[0].BBox != [4]float64{10, 20, 60, 35} {
+        t.Errorf("unexpected first word: %+v", words[0])
+    }
+    if words[1].Text != "World" {
+        t.Errorf("unexpected second word: %+v", words[1])
+    }
+}
+
+func TestStreamFilterNames(t *testing.T) {
+    single := &types.StreamDict{Dict: types.Dict{"Filter": types.Name("CCITTFaxDecode")}}
+    if got := streamFilterNames(single); len(got) != 1 || got[0] != "CCITTFaxDecode" {
+        t.Errorf("expected [CCITTFaxDecode], got %v", got)
+    }
+
+    chained := &types.StreamDict{Dict: types.Dict{"Filter": types.Array{types.Name("ASCII85Decode"), types.Name("DCTDecode")}}}
+    if got := streamFilterNames(chained); len(got) != 2 || got[1] != "DCTDecode" {
+        t.Errorf("expected [ASCII85Decode DCTDecode], got %v", got)
+    }
+
+    none := &types.StreamDict{Dict: types.Dict{}}
+    if got := streamFilterNames(none); got != nil {
+        t.Errorf("expected nil for a stream with no /Filter, got %v", got)
+    }
+}
+
+func TestExtractPageImage_UnsupportedFilterIsReportedDistinctly(t *testing.T) {
+    if !unsupportedImageFilters["CCITTFaxDecode"] {
+        t.Error("expected CCITTFaxDecode to be flagged unsupported")
+    }
+    if !unsupportedImageFilters["JBIG2Decode"] {
+        t.Error("expected JBIG2Decode to be flagged unsupported")
+    }
+    if unsupportedImageFilters["DCTDecode"] {
+        t.Error("expected DCTDecode (plain JPEG) to be supported")
+    }
+
+    err := &unsupportedImageFilterError{filter: "CCITTFaxDecode"}
+    var unsupported *unsupportedImageFilterError
+    if !errors.As(err, &unsupported) {
+        t.Fatal("expected errors.As to match unsupportedImageFilterError")
+    }
+    if unsupported.filter != "CCITTFaxDecode" {
+        t.Errorf("filter = %q, want CCITTFaxDecode", unsupported.filter)
+    }
+}
+
+func TestMediaBoxFor_ParsesRealRectangle(t *testing.T) {
+    pageDict := types.Dict{"MediaBox": types.Array{
+        types.Integer(0), types.Integer(0), types.Integer(612), types.Integer(792),
+    }}
+
+    mediaBox, err := mediaBoxFor(nil, pageDict)
+    if err != nil {
+        t.Fatalf("mediaBoxFor: %v", err)
+    }
+    want := types.NewRectangle(0, 0, 612, 792)
+    if mediaBox.LL != want.LL || mediaBox.UR != want.UR {
+        t.Errorf("mediaBox = %+v, want %+v", mediaBox, want)
+    }
+}
+
+func TestMediaBoxFor_MissingMediaBoxDefaultsToUSLetter(t *testing.T) {
+    mediaBox, err := mediaBoxFor(nil, types.Dict{})
+    if err != nil {
+        t.Fatalf("mediaBoxFor: %v", err)
+    }
+    want := types.NewRectangle(0, 0, 612, 792)
+    if mediaBox.LL != want.LL || mediaBox.UR != want.UR {
+        t.Errorf("expected US Letter default, got %+v", mediaBox)
+    }
+}
+
+// TestOCRWordToPageRect_ConvertsPixelSpaceToPDFSpace pins down the
+// pixel-to-user-space math against a real *types.Rectangle built the same
+// way mediaBoxFor does, including the top-down-to-bottom-up Y flip.
+func TestOCRWordToPageRect_ConvertsPixelSpaceToPDFSpace(t *testing.T) {
+    mediaBox := types.NewRectangle(0, 0, 612, 792)
+    img := image.NewGray(image.Rect(0, 0, 1224, 1584)) // 2x the MediaBox, in pixels
+
+    word := OCRWord{Text: "Hello", BBox: [4]float64{0, 0, 100, 20}}
+    r := ocrWordToPageRect(word, img, mediaBox, ModeBlackbox)
+
+    if r.x != 0 {
+        t.Errorf("x = %v, want 0", r.x)
+    }
+    if w := r.w; w < 49.9 || w > 50.1 {
+        t.Errorf("w = %v, want ~50 (100px at 0.5 scale)", w)
+    }
+    // The word sits at the top of the image, which is the top of the
+    // page; top-down pixel Y must map to bottom-up PDF Y near MediaBox.UR.Y.
+    wantY := 792.0 - 20.0/1584.0*792.0
+    if diff := r.y - wantY; diff > 0.01 || diff < -0.01 {
+        t.Errorf("y = %v, want %v", r.y, wantY)
+    }
+}
+
+func TestNormalizeModeForOCR(t *testing.T) {
+    if normalizeModeForOCR(ModeWhiteout) != ModeWhiteout {
+        t.Error("expected whiteout to pass through")
+    }
+    if normalizeModeForOCR(ModeReplace) != ModeBlackbox {
+        t.Error("expected replace mode to default to blackbox for OCR words")
+    }
+    if normalizeModeForOCR("") != ModeBlackbox {
+        t.Error("expected empty mode to default to blackbox for OCR words")
+    }
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+    "strconv"
+    "strings"
+
+    "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+    "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// winAnsiTable covers the printable ASCII range plus the WinAnsiEncoding
+// additions in 0x80-0x9F that regularly show up in real-world PDFs
+// (smart quotes, em dash, etc). It's the builtin encoding assumed for any
+// simple font that doesn't override a code via /Differences.
+var winAnsiTable = buildWinAnsiTable()
+
+func buildWinAnsiTable() map[uint32]rune {
+    m := make(map[uint32]rune, 224)
+    for c := 32; c <= 126; c++ {
+        m[uint32(c)] = rune(c)
+    }
+    extra := map[uint32]rune{
+        0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”',
+        0x96: '–', 0x97: '—', 0x85: '…', 0xA0: ' ',
+    }
+    for c, r := range extra {
+        m[c] = r
+    }
+    for c := 0xA1; c <= 0xFF; c++ {
+        m[uint32(c)] = rune(c)
+    }
+    return m
+}
+
+// buildGlyphFont derives a GlyphFont from a page's font resource dict, so
+// redactContentStream can decode/re-encode show-text operands for that
+// font. Composite (Type0) fonts are assumed to use an Identity-H/V CMap,
+// which covers the overwhelming majority of CID fonts pdfcpu's writer
+// itself produces and that PDF producers use for embedded subsets.
+func buildGlyphFont(xRefTable *model.XRefTable, fontDict types.Dict) (*GlyphFont, error) {
+    subtype := fontDict.NameEntry("Subtype")
+    if subtype != nil && *subtype == "Type0" {
+        return buildCIDFont(xRefTable, fontDict)
+    }
+    return buildSimpleFont(xRefTable, fontDict)
+}
+
+func buildSimpleFont(xRefTable *model.XRefTable, fontDict types.Dict) (*GlyphFont, error) {
+    f := &GlyphFont{
+        Decode:       map[uint32]rune{},
+        Encode:       map[rune]uint32{},
+        Widths:       map[uint32]float64{},
+        DefaultWidth: 500,
+    }
+    for code, r := range winAnsiTable {
+        f.Decode[code] = r
+    }
+
+    if diffs, err := resolveDifferences(xRefTable, fontDict); err == nil {
+        for code, r := range diffs {
+            f.Decode[code] = r
+        }
+    }
+
+    firstChar := 0
+    if fc, err := xRefTable.DereferenceInteger(fontDict["FirstChar"]); err == nil && fc != nil {
+        firstChar = fc.Value()
+    }
+    if arr, err := xRefTable.DereferenceArray(fontDict["Widths"]); err == nil {
+        for i, w := range arr {
+            if num, ok := w.(types.Float); ok {
+                f.Widths[uint32(firstChar+i)] = num.Value()
+            } else if in, ok := w.(types.Integer); ok {
+                f.Widths[uint32(firstChar+i)] = float64(in.Value())
+            }
+        }
+    }
+
+    for code, r := range f.Decode {
+        f.Encode[r] = code
+    }
+    return f, nil
+}
+
+func buildCIDFont(xRefTable *model.XRefTable, fontDict types.Dict) (*GlyphFont, error) {
+    f := &GlyphFont{
+        CID:          true,
+        Decode:       map[uint32]rune{},
+        Encode:       map[rune]uint32{},
+        Widths:       map[uint32]float64{},
+        DefaultWidth: 1000,
+    }
+
+    if tu, _, err := xRefTable.DereferenceStreamDict(fontDict["ToUnicode"]); err == nil && tu != nil {
+        if err := tu.Decode(); err == nil {
+            parseToUnicodeCMap(string(tu.Content), f.Decode)
+        }
+    }
+
+    if descendants, err := xRefTable.DereferenceArray(fontDict["DescendantFonts"]); err == nil && len(descendants) == 1 {
+        if cidFontDict, err := xRefTable.DereferenceDict(descendants[0]); err == nil {
+            if dw, err := xRefTable.DereferenceInteger(cidFontDict["DW"]); err == nil && dw != nil {
+                f.DefaultWidth = float64(dw.Value())
+            }
+            if w, err := xRefTable.DereferenceArray(cidFontDict["W"]); err == nil {
+                parseCIDWidths(xRefTable, w, f.Widths)
+            }
+        }
+    }
+
+    for code, r := range f.Decode {
+        f.Encode[r] = code
+    }
+    return f, nil
+}
+
+// resolveDifferences reads /Encoding /Differences, mapping overridden
+// codes to Unicode via a small table of the glyph names redaction text
+// is most likely to need; anything it doesn't recognize is left to the
+// base WinAnsi table.
+func resolveDifferences(xRefTable *model.XRefTable, fontDict types.Dict) (map[uint32]rune, error) {
+    out := map[uint32]rune{}
+    encObj, found := fontDict["Encoding"]
+    if !found {
+        return out, nil
+    }
+    encDict, err := xRefTable.DereferenceDict(encObj)
+    if err != nil || encDict == nil {
+        return out, nil
+    }
+    diffs, err := xRefTable.DereferenceArray(encDict["Differences"])
+    if err != nil {
+        return out, nil
+    }
+    code := 0
+    for _, e := range diffs {
+        switch v := e.(type) {
+        case types.Integer:
+            code = v.Value()
+        case types.Name:
+            if r, ok := glyphNameToRune(string(v)); ok {
+                out[uint32(code)] = r
+            }
+            code++
+        }
+    }
+    return out, nil
+}
+
+var glyphNames = map[string]rune{
+    "space": ' ', "quotesingle": '\'', "quotedbl": '"',
+    "hyphen": '-', "period": '.', "comma": ',', "at": '@',
+    "underscore": '_', "emdash": '—', "endash": '–',
+    "quoteleft": '‘', "quoteright": '’',
+    "quotedblleft": '“', "quotedblright": '”',
+}
+
+func glyphNameToRune(name string) (rune, bool) {
+    if r, ok := glyphNames[name]; ok {
+        return r, true
+    }
+    if strings.HasPrefix(name, "uni") && len(name) == 7 {
+        if v, err := strconv.ParseUint(name[3:], 16, 32); err == nil {
+            return rune(v), true
+        }
+    }
+    return 0, false
+}
+
+// parseToUnicodeCMap extracts bfchar/bfrange mappings from a ToUnicode
+// CMap stream. Each code maps to a single rune: a destination longer
+// than one UTF-16 unit (a ligature, or a codepoint outside the BMP) is
+// cut to its first unit.
+func parseToUnicodeCMap(content string, out map[uint32]rune) {
+    parseBfSection(content, "beginbfchar", "endbfchar", out, false)
+    parseBfSection(content, "beginbfrange", "endbfrange", out, true)
+}
+
+func parseBfSection(content, begin, end string, out map[uint32]rune, isRange bool) {
+    for {
+        start := strings.Index(content, begin)
+        if start == -1 {
+            return
+        }
+        stop := strings.Index(content[start:], end)
+        if stop == -1 {
+            return
+        }
+        body := content[start+len(begin) : start+stop]
+        content = content[start+stop+len(end):]
+
+        tokens := extractHexTokens(body)
+        step := 2
+        if isRange {
+            step = 3
+        }
+        for i := 0; i+step <= len(tokens); i += step {
+            lo, err1 := strconv.ParseUint(tokens[i], 16, 32)
+            if err1 != nil {
+                continue
+            }
+            if isRange {
+                hi, err2 := strconv.ParseUint(tokens[i+1], 16, 32)
+                dst, err3 := strconv.ParseUint(tokens[i+2], 16, 32)
+                if err2 != nil || err3 != nil {
+                    continue
+                }
+                for c := lo; c <= hi; c++ {
+                    out[uint32(c)] = rune(dst + (c - lo))
+                }
+            } else {
+                dst, err2 := strconv.ParseUint(tokens[i+1], 16, 32)
+                if err2 != nil {
+                    continue
+                }
+                out[uint32(lo)] = rune(dst)
+            }
+        }
+    }
+}
+
+func extractHexTokens(body string) []string {
+    var tokens []string
+    i := 0
+    for i < len(body) {
+        if body[i] == '<' {
+            j := strings.IndexByte(body[i:], '>')
+            if j == -1 {
+                break
+            }
+            hex := body[i+1 : i+j]
+            // A ToUnicode destination may be a UTF-16BE string longer than
+            // one codepoint; we only care about the first codepoint.
+            if len(hex) > 4 {
+                hex = hex[:4]
+            }
+            tokens = append(tokens, hex)
+            i += j + 1
+        } else {
+            i++
+        }
+    }
+    return tokens
+}
+
+func parseCIDWidths(xRefTable *model.XRefTable, w []types.Object, out map[uint32]float64) {
+    i := 0
+    for i < len(w) {
+        first, ok := asInt(w[i])
+        if !ok {
+            i++
+            continue
+        }
+        i++
+        if i >= len(w) {
+            return
+        }
+        if arr, ok := w[i].(types.Array); ok {
+            for j, wv := range arr {
+                if width, ok := asFloat(wv); ok {
+                    out[uint32(first+j)] = width
+                }
+            }
+            i++
+            continue
+        }
+        last, ok := asInt(w[i])
+        if !ok || i+1 >= len(w) {
+            return
+        }
+        width, ok := asFloat(w[i+1])
+        if !ok {
+            return
+        }
+        for c := first; c <= last; c++ {
+            out[uint32(c)] = width
+        }
+        i += 2
+    }
+}
+
+func asInt(o types.Object) (int, bool) {
+    switch v := o.(type) {
+    case types.Integer:
+        return v.Value(), true
+    case types.Float:
+        return int(v.Value()), true
+    }
+    return 0, false
+}
+
+func asFloat(o types.Object) (float64, bool) {
+    switch v := o.(type) {
+    case types.Integer:
+        return float64(v.Value()), true
+    case types.Float:
+        return v.Value(), true
+    }
+    return 0, false
+}
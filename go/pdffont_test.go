@@ -0,0 +1,155 @@
+package main
+
+import (
+    "bytes"
+    "compress/zlib"
+    "testing"
+
+    "github.com/pdfcpu/pdfcpu/pkg/filter"
+    "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+func TestBuildSimpleFont_ASCIIWidths(t *testing.T) {
+    fontDict := types.Dict{
+        "Subtype":   types.Name("Type1"),
+        "FirstChar": types.Integer(32),
+        "Widths":    types.NewIntegerArray(278, 278, 355, 556),
+    }
+
+    f, err := buildSimpleFont(nil, fontDict)
+    if err != nil {
+        t.Fatalf("buildSimpleFont: %v", err)
+    }
+    if f.CID {
+        t.Fatal("expected a simple (non-CID) font")
+    }
+    if r := f.Decode[65]; r != 'A' {
+        t.Errorf("expected code 65 to decode to 'A' via WinAnsi, got %q", r)
+    }
+    if w := f.Widths[32]; w != 278 {
+        t.Errorf("expected code 32 (FirstChar) width 278, got %v", w)
+    }
+    if w := f.Widths[35]; w != 556 {
+        t.Errorf("expected code 35 width 556, got %v", w)
+    }
+}
+
+func TestBuildSimpleFont_DifferencesOverrideWinAnsi(t *testing.T) {
+    fontDict := types.Dict{
+        "Subtype": types.Name("Type1"),
+        "Encoding": types.Dict{
+            "Differences": types.Array{
+                types.Integer(65), types.Name("space"), types.Name("hyphen"),
+            },
+        },
+    }
+
+    f, err := buildSimpleFont(nil, fontDict)
+    if err != nil {
+        t.Fatalf("buildSimpleFont: %v", err)
+    }
+    if r := f.Decode[65]; r != ' ' {
+        t.Errorf("expected /Differences to remap code 65 to space, got %q", r)
+    }
+    if r := f.Decode[66]; r != '-' {
+        t.Errorf("expected /Differences to remap code 66 to hyphen, got %q", r)
+    }
+    // Codes untouched by /Differences still fall back to WinAnsi.
+    if r := f.Decode[67]; r != 'C' {
+        t.Errorf("expected code 67 to keep its WinAnsi mapping, got %q", r)
+    }
+}
+
+func TestResolveDifferences_NoEncodingReturnsEmpty(t *testing.T) {
+    diffs, err := resolveDifferences(nil, types.Dict{})
+    if err != nil {
+        t.Fatalf("resolveDifferences: %v", err)
+    }
+    if len(diffs) != 0 {
+        t.Errorf("expected no differences without an /Encoding entry, got %v", diffs)
+    }
+}
+
+func TestParseToUnicodeCMap_BfCharAndBfRange(t *testing.T) {
+    content := "" +
+        "1 beginbfchar\n" +
+        "<0041> <0041>\n" +
+        "endbfchar\n" +
+        "1 beginbfrange\n" +
+        "<0001> <0003> <0061>\n" +
+        "endbfrange\n"
+
+    out := map[uint32]rune{}
+    parseToUnicodeCMap(content, out)
+
+    if r := out[0x41]; r != 'A' {
+        t.Errorf("expected bfchar 0x41 -> 'A', got %q", r)
+    }
+    if r := out[1]; r != 'a' {
+        t.Errorf("expected bfrange 1 -> 'a', got %q", r)
+    }
+    if r := out[3]; r != 'c' {
+        t.Errorf("expected bfrange 3 -> 'c' (lo+2), got %q", r)
+    }
+}
+
+func zlibCompress(t *testing.T, s string) []byte {
+    t.Helper()
+    var buf bytes.Buffer
+    w := zlib.NewWriter(&buf)
+    if _, err := w.Write([]byte(s)); err != nil {
+        t.Fatalf("zlib.Write: %v", err)
+    }
+    if err := w.Close(); err != nil {
+        t.Fatalf("zlib.Close: %v", err)
+    }
+    return buf.Bytes()
+}
+
+// TestBuildCIDFont_ToUnicodeAndWidths exercises the CID-font path end to
+// end against a directly-constructed (non-indirect) fontDict, including a
+// real FlateDecode-compressed ToUnicode CMap stream, mirroring how a
+// Type0 font with an embedded subset is described in practice.
+func TestBuildCIDFont_ToUnicodeAndWidths(t *testing.T) {
+    cmap := "" +
+        "1 beginbfchar\n" +
+        "<0064> <0041>\n" + // CID 100 -> 'A'
+        "endbfchar\n"
+
+    toUnicode := types.NewStreamDict(
+        types.Dict{},
+        0, nil, nil,
+        []types.PDFFilter{{Name: filter.Flate}},
+    )
+    toUnicode.Raw = zlibCompress(t, cmap)
+
+    descendant := types.Dict{
+        "DW": types.Integer(1000),
+        "W": types.Array{
+            types.Integer(100), types.Array{types.Integer(250)},
+        },
+    }
+
+    fontDict := types.Dict{
+        "Subtype":         types.Name("Type0"),
+        "ToUnicode":       toUnicode,
+        "DescendantFonts": types.Array{descendant},
+    }
+
+    f, err := buildCIDFont(nil, fontDict)
+    if err != nil {
+        t.Fatalf("buildCIDFont: %v", err)
+    }
+    if !f.CID {
+        t.Fatal("expected a CID font")
+    }
+    if r := f.Decode[0x64]; r != 'A' {
+        t.Errorf("expected CID 0x64 to decode to 'A' via ToUnicode, got %q", r)
+    }
+    if w := f.Widths[0x64]; w != 250 {
+        t.Errorf("expected CID 0x64 width 250 from /W, got %v", w)
+    }
+    if f.DefaultWidth != 1000 {
+        t.Errorf("expected DefaultWidth from /DW (1000), got %v", f.DefaultWidth)
+    }
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+    "fmt"
+
+    "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+    "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// redactText walks every page's content stream, decodes show-text
+// operands via that page's fonts, applies the configured replacement
+// rules, and writes the rewritten content stream back into ctx. It
+// returns the total number of text runs that were changed.
+func (r *PDFRedactor) redactText(ctx *model.Context) (int, error) {
+    xRefTable := ctx.XRefTable
+    totalMatches := 0
+
+    for pageNr := 1; pageNr <= xRefTable.PageCount; pageNr++ {
+        pageDict, _, _, err := xRefTable.PageDict(pageNr, false)
+        if err != nil {
+            return totalMatches, fmt.Errorf("redactText: page %d: %v", pageNr, err)
+        }
+        if pageDict == nil {
+            continue
+        }
+
+        fonts, err := pageFonts(xRefTable, pageDict)
+        if err != nil {
+            return totalMatches, fmt.Errorf("redactText: page %d fonts: %v", pageNr, err)
+        }
+
+        raw, err := pageContentBytes(xRefTable, pageDict)
+        if err != nil {
+            return totalMatches, fmt.Errorf("redactText: page %d content: %v", pageNr, err)
+        }
+        if len(raw) == 0 {
+            continue
+        }
+
+        rewritten, n, err := redactContentStream(raw, fonts, r.replacements)
+        if err != nil {
+            return totalMatches, fmt.Errorf("redactText: page %d: %v", pageNr, err)
+        }
+        if n == 0 {
+            continue
+        }
+        totalMatches += n
+
+        if err := setPageContentBytes(xRefTable, pageDict, rewritten); err != nil {
+            return totalMatches, fmt.Errorf("redactText: page %d write: %v", pageNr, err)
+        }
+    }
+
+    return totalMatches, nil
+}
+
+// pageFonts resolves every entry of a page's /Resources /Font dict into
+// a GlyphFont, keyed by the resource name (e.g. "F1") used in Tf
+// operators.
+func pageFonts(xRefTable *model.XRefTable, pageDict types.Dict) (map[string]*GlyphFont, error) {
+    return resourceFonts(xRefTable, pageDict["Resources"])
+}
+
+// resourceFonts resolves every entry of any /Resources dict's /Font
+// sub-dictionary into a GlyphFont. It's shared by page content (via
+// pageFonts) and by AcroForm field appearance streams, whose /DR
+// (default resources) entry has the same shape.
+func resourceFonts(xRefTable *model.XRefTable, resourcesRef types.Object) (map[string]*GlyphFont, error) {
+    fonts := map[string]*GlyphFont{}
+
+    resDict, err := xRefTable.DereferenceDict(resourcesRef)
+    if err != nil || resDict == nil {
+        return fonts, nil
+    }
+    fontDicts, err := xRefTable.DereferenceDict(resDict["Font"])
+    if err != nil || fontDicts == nil {
+        return fonts, nil
+    }
+
+    for name, ref := range fontDicts {
+        fontDict, err := xRefTable.DereferenceDict(ref)
+        if err != nil || fontDict == nil {
+            continue
+        }
+        font, err := buildGlyphFont(xRefTable, fontDict)
+        if err != nil {
+            continue
+        }
+        fonts[name] = font
+    }
+
+    return fonts, nil
+}
+
+// pageContentBytes returns a page's decoded content stream, concatenating
+// the array form (/Contents [stream stream ...]) with a newline between
+// entries as the PDF spec requires.
+func pageContentBytes(xRefTable *model.XRefTable, pageDict types.Dict) ([]byte, error) {
+    obj, found := pageDict["Contents"]
+    if !found {
+        return nil, nil
+    }
+
+    if sd, _, err := xRefTable.DereferenceStreamDict(obj); err == nil && sd != nil {
+        if err := sd.Decode(); err != nil {
+            return nil, err
+        }
+        return sd.Content, nil
+    }
+
+    arr, err := xRefTable.DereferenceArray(obj)
+    if err != nil {
+        return nil, err
+    }
+    var out []byte
+    for _, e := range arr {
+        sd, _, err := xRefTable.DereferenceStreamDict(e)
+        if err != nil || sd == nil {
+            continue
+        }
+        if err := sd.Decode(); err != nil {
+            continue
+        }
+        out = append(out, sd.Content...)
+        out = append(out, '\n')
+    }
+    return out, nil
+}
+
+// setPageContentBytes replaces a page's content with a single new stream
+// object, collapsing a multi-stream /Contents array in the process. This
+// is simpler and just as valid as rewriting each original stream in
+// place, since nothing else references a page's content streams.
+func setPageContentBytes(xRefTable *model.XRefTable, pageDict types.Dict, content []byte) error {
+    sd, err := xRefTable.NewStreamDictForBuf(content)
+    if err != nil {
+        return err
+    }
+    if err := sd.Encode(); err != nil {
+        return err
+    }
+    ir, err := xRefTable.IndRefForNewObject(sd)
+    if err != nil {
+        return err
+    }
+    pageDict["Contents"] = *ir
+    return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// presetDefinition describes one built-in PII pattern: the regex to
+// match candidates, the token to replace a validated match with, and an
+// optional validator that filters out candidates that match the shape
+// but fail a checksum (e.g. a 16-digit number that isn't a real card).
+type presetDefinition struct {
+    pattern   string
+    token     string
+    validator func(match string) bool
+}
+
+var presetRegistry = map[string]presetDefinition{
+    "us-ssn": {
+        pattern:   `\b\d{3}-\d{2}-\d{4}\b`,
+        token:     "[SSN]",
+        validator: ssnValid,
+    },
+    "credit-card": {
+        pattern:   `\b(?:\d[ -]?){13,16}\d\b`,
+        token:     "[CARD]",
+        validator: func(m string) bool { return luhnValid(onlyDigits(m)) },
+    },
+    "email": {
+        pattern: `\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b`,
+        token:   "[EMAIL]",
+    },
+    "us-phone": {
+        pattern: `\(?\b\d{3}\)?[-. ]\d{3}[-. ]\d{4}\b`,
+        token:   "[PHONE]",
+    },
+    "iban": {
+        pattern:   `\b[A-Z]{2}\d{2}[A-Z0-9]{11,30}\b`,
+        token:     "[IBAN]",
+        validator: ibanValid,
+    },
+    "ipv4": {
+        pattern: `\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`,
+        token:   "[IP]",
+    },
+    "ipv6": {
+        pattern: `\b(?:[A-Fa-f0-9]{1,4}:){7}[A-Fa-f0-9]{1,4}\b`,
+        token:   "[IPV6]",
+    },
+    "aws-key": {
+        pattern: `\bAKIA[0-9A-Z]{16}\b`,
+        token:   "[AWS_KEY]",
+    },
+}
+
+// expandPreset turns a preset name into the ReplacementRule(s) it stands
+// for. Presets are plain ReplacementRules under the hood, so they
+// compose with user-authored rules; the expanded rules run in replace
+// mode, substituting the preset's own token.
+func expandPreset(name string) ([]ReplacementRule, error) {
+    def, ok := presetRegistry[name]
+    if !ok {
+        return nil, fmt.Errorf("unknown preset %q", name)
+    }
+    return []ReplacementRule{
+        {
+            Find:      def.pattern,
+            Replace:   def.token,
+            Regex:     true,
+            Mode:      ModeReplace,
+            Validator: def.validator,
+        },
+    }, nil
+}
+
+func onlyDigits(s string) string {
+    var b strings.Builder
+    for _, r := range s {
+        if r >= '0' && r <= '9' {
+            b.WriteRune(r)
+        }
+    }
+    return b.String()
+}
+
+// luhnValid implements the Luhn checksum: starting from the rightmost
+// digit, double every second digit (subtracting 9 if that exceeds 9),
+// sum everything, and check the total is a multiple of 10.
+func luhnValid(digits string) bool {
+    if digits == "" {
+        return false
+    }
+    sum := 0
+    double := false
+    for i := len(digits) - 1; i >= 0; i-- {
+        c := digits[i]
+        if c < '0' || c > '9' {
+            return false
+        }
+        d := int(c - '0')
+        if double {
+            d *= 2
+            if d > 9 {
+                d -= 9
+            }
+        }
+        sum += d
+        double = !double
+    }
+    return sum%10 == 0
+}
+
+// ibanValid implements the IBAN mod-97 check: move the first four
+// characters to the end, convert letters to their A=10..Z=35 digit
+// values, and verify the resulting number is congruent to 1 mod 97.
+func ibanValid(s string) bool {
+    s = strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+    if len(s) < 4 {
+        return false
+    }
+    rearranged := s[4:] + s[:4]
+
+    var digits strings.Builder
+    for _, r := range rearranged {
+        switch {
+        case r >= '0' && r <= '9':
+            digits.WriteRune(r)
+        case r >= 'A' && r <= 'Z':
+            digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+        default:
+            return false
+        }
+    }
+
+    remainder := 0
+    for _, r := range digits.String() {
+        remainder = (remainder*10 + int(r-'0')) % 97
+    }
+    return remainder == 1
+}
+
+// ssnValid rejects area/group/serial combinations the SSA has never
+// issued: area 000, 666, or 900-999; group 00; or serial 0000.
+func ssnValid(match string) bool {
+    digits := onlyDigits(match)
+    if len(digits) != 9 {
+        return false
+    }
+    area, err1 := strconv.Atoi(digits[0:3])
+    group, err2 := strconv.Atoi(digits[3:5])
+    serial, err3 := strconv.Atoi(digits[5:9])
+    if err1 != nil || err2 != nil || err3 != nil {
+        return false
+    }
+    if area == 0 || area == 666 || area >= 900 {
+        return false
+    }
+    if group == 0 {
+        return false
+    }
+    if serial == 0 {
+        return false
+    }
+    return true
+}
@@ -0,0 +1,72 @@
+package main
+
+import "testing"
+
+func TestLuhnValid(t *testing.T) {
+    cases := map[string]bool{
+        "4111111111111111": true,  // well-known Visa test number
+        "4111111111111112": false, // bad check digit
+        "379354508162306":  true,  // Amex test number
+        "":                 false,
+        "abcd":              false,
+    }
+    for digits, want := range cases {
+        if got := luhnValid(digits); got != want {
+            t.Errorf("luhnValid(%q) = %v, want %v", digits, got, want)
+        }
+    }
+}
+
+func TestIBANValid(t *testing.T) {
+    cases := map[string]bool{
+        "GB82WEST12345698765432": true,  // textbook example
+        "GB82WEST12345698765433": false, // corrupted check
+        "DE89370400440532013000": true,
+        "XX":                     false,
+    }
+    for iban, want := range cases {
+        if got := ibanValid(iban); got != want {
+            t.Errorf("ibanValid(%q) = %v, want %v", iban, got, want)
+        }
+    }
+}
+
+func TestSSNValid(t *testing.T) {
+    cases := map[string]bool{
+        "123-45-6789": true,
+        "000-45-6789": false, // invalid area
+        "666-45-6789": false, // invalid area
+        "900-45-6789": false, // invalid area (900-999)
+        "123-00-6789": false, // invalid group
+        "123-45-0000": false, // invalid serial
+    }
+    for ssn, want := range cases {
+        if got := ssnValid(ssn); got != want {
+            t.Errorf("ssnValid(%q) = %v, want %v", ssn, got, want)
+        }
+    }
+}
+
+func TestExpandPreset_UnknownName(t *testing.T) {
+    if _, err := expandPreset("does-not-exist"); err == nil {
+        t.Fatal("expected an error for an unknown preset name")
+    }
+}
+
+func TestProcessText_CreditCardPresetSkipsInvalidLuhn(t *testing.T) {
+    rules, err := expandPreset("credit-card")
+    if err != nil {
+        t.Fatalf("expandPreset: %v", err)
+    }
+    r := &PDFRedactor{replacements: rules}
+
+    valid := r.ProcessText("Card: 4111111111111111")
+    if valid != "Card: [CARD]" {
+        t.Errorf("expected valid card to be redacted, got %q", valid)
+    }
+
+    invalid := r.ProcessText("Card: 4111111111111112")
+    if invalid != "Card: 4111111111111112" {
+        t.Errorf("expected invalid Luhn number to survive, got %q", invalid)
+    }
+}
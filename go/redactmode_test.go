@@ -0,0 +1,200 @@
+package main
+
+import (
+    "strings"
+    "testing"
+)
+
+func ssnBlackboxRule() []ReplacementRule {
+    return []ReplacementRule{
+        {Find: `\d{3}-\d{2}-\d{4}`, Regex: true, Mode: ModeBlackbox},
+    }
+}
+
+func TestRedactContentStream_Blackbox_PaintsRectAndStripsText(t *testing.T) {
+    stream := []byte("BT /F1 12 Tf 1 0 0 1 72 700 Tm (SSN: 123-45-6789) Tj ET")
+    fonts := map[string]*GlyphFont{"F1": asciiFont()}
+
+    out, n, err := redactContentStream(stream, fonts, ssnBlackboxRule())
+    if err != nil {
+        t.Fatalf("redactContentStream: %v", err)
+    }
+    if n != 1 {
+        t.Fatalf("expected 1 changed run, got %d", n)
+    }
+    s := string(out)
+    if strings.Contains(s, "123-45-6789") {
+        t.Fatalf("SSN digits still present in content stream: %s", s)
+    }
+    if !strings.Contains(s, "re\nf\n") {
+        t.Fatalf("expected an re/f fill op for the redaction rectangle, got: %s", s)
+    }
+    if !strings.Contains(s, "0 g") {
+        t.Fatalf("expected a black (0 g) fill for blackbox mode, got: %s", s)
+    }
+    // The rect op(s) must come after ET, not inside the text object.
+    if strings.Index(s, "ET") > strings.Index(s, "re") {
+        t.Fatalf("expected rect ops after ET, got: %s", s)
+    }
+}
+
+func TestRedactContentStream_Whiteout_UsesWhiteFill(t *testing.T) {
+    rule := []ReplacementRule{{Find: `\d{3}-\d{2}-\d{4}`, Regex: true, Mode: ModeWhiteout}}
+    stream := []byte("BT /F1 12 Tf (SSN: 123-45-6789) Tj ET")
+    fonts := map[string]*GlyphFont{"F1": asciiFont()}
+
+    out, n, err := redactContentStream(stream, fonts, rule)
+    if err != nil {
+        t.Fatalf("redactContentStream: %v", err)
+    }
+    if n != 1 {
+        t.Fatalf("expected 1 changed run, got %d", n)
+    }
+    if !strings.Contains(string(out), "1 g") {
+        t.Fatalf("expected a white (1 g) fill for whiteout mode, got: %s", out)
+    }
+}
+
+func TestRedactContentStream_ReplacePreservesWidth_WithTJKerning(t *testing.T) {
+    // [(123-) -100 (45-6789)] TJ: a kerning number sits inside the array
+    // alongside the strings being matched/replaced.
+    stream := []byte("BT /F1 12 Tf [(123-) -100 (45-6789)] TJ ET")
+    rule := []ReplacementRule{{Find: `123-45-6789`, Regex: true, Replace: "X", Mode: ModeReplace}}
+    fonts := map[string]*GlyphFont{"F1": asciiFont()}
+
+    out, n, err := redactContentStream(stream, fonts, rule)
+    if err != nil {
+        t.Fatalf("redactContentStream: %v", err)
+    }
+    if n != 1 {
+        t.Fatalf("expected 1 changed run, got %d", n)
+    }
+
+    ops, err := parseContentStream(out)
+    if err != nil {
+        t.Fatalf("parseContentStream: %v", err)
+    }
+    var tj *csOp
+    for i := range ops {
+        if ops[i].Operator == "TJ" {
+            tj = &ops[i]
+        }
+    }
+    if tj == nil {
+        t.Fatalf("expected a TJ op in output: %s", out)
+    }
+    arr, ok := tj.Operands[0].([]csObject)
+    if !ok || len(arr) != 2 {
+        t.Fatalf("expected a [bytes, number] TJ array, got: %+v", tj.Operands)
+    }
+    adj, ok := arr[1].(float64)
+    if !ok {
+        t.Fatalf("expected a numeric adjustment, got: %+v", arr[1])
+    }
+    // origWidth includes the original -100 kerning number
+    // (11 glyphs * 500/1000*12 = 66, plus 100/1000*12 = 1.2 => 67.2);
+    // newWidth is "X" alone (500/1000*12 = 6). Omitting the kerning
+    // number from origWidth would understate the deficit by 1.2.
+    const wantAdj = -5100.0
+    if diff := adj - wantAdj; diff > 0.01 || diff < -0.01 {
+        t.Errorf("adjustment = %v, want %v (kerning number must count toward original width)", adj, wantAdj)
+    }
+}
+
+func TestBuildPaddedOp_LongerReplacementSplitsOntoNextLine(t *testing.T) {
+    run := &textRun{
+        font:     asciiFont(),
+        fontSize: 12,
+        leading:  14,
+        codes:    []uint32{'X'}, // a single narrow original glyph
+    }
+    finalText := strings.Repeat("Y", 20) // much wider than the original run
+
+    ops := buildPaddedOp(run, finalText)
+    if len(ops) != 3 {
+        t.Fatalf("expected a 3-op split (Tj, Td, Tj), got %d ops: %+v", len(ops), ops)
+    }
+    if ops[0].Operator != "Tj" || ops[2].Operator != "Tj" {
+        t.Fatalf("expected Tj ops at positions 0 and 2, got: %+v", ops)
+    }
+    if ops[1].Operator != "Td" {
+        t.Fatalf("expected a Td line break between the two Tj ops, got: %+v", ops)
+    }
+    ty, ok := ops[1].Operands[1].(float64)
+    if !ok || ty != -14 {
+        t.Fatalf("expected Td to move down by the run's leading (-14), got: %+v", ops[1].Operands)
+    }
+}
+
+func TestRedactContentStream_TfSwitchFlushesRunBeforeFontChange(t *testing.T) {
+    // A mid-run Tf switch to a font with a different code width (1-byte
+    // simple font -> 2-byte CID font) must not let the second Tj's codes
+    // get packed using the first font's width, which would corrupt both
+    // runs' byte encoding.
+    f2 := cidFont()
+    _, f2Bytes := f2.encodeRunes("hello")
+    var hex strings.Builder
+    for _, b := range f2Bytes {
+        hex.WriteString(hexByte(b))
+    }
+    stream := []byte("BT /F1 12 Tf (SSN: 123-45-6789) Tj /F2 12 Tf <" + hex.String() + "> Tj ET")
+    fonts := map[string]*GlyphFont{"F1": asciiFont(), "F2": f2}
+
+    out, n, err := redactContentStream(stream, fonts, ssnBlackboxRule())
+    if err != nil {
+        t.Fatalf("redactContentStream: %v", err)
+    }
+    if n != 1 {
+        t.Fatalf("expected 1 changed run, got %d", n)
+    }
+
+    ops, err := parseContentStream(out)
+    if err != nil {
+        t.Fatalf("parseContentStream(out): %v", err)
+    }
+    var tjOps []csOp
+    for _, op := range ops {
+        if op.Operator == "Tj" && len(op.Operands) > 0 {
+            tjOps = append(tjOps, op)
+        }
+    }
+    if len(tjOps) != 2 {
+        t.Fatalf("expected 2 separate Tj ops (one per font run), got %d: %+v", len(tjOps), tjOps)
+    }
+    s, ok := tjOps[1].Operands[0].([]byte)
+    if !ok {
+        t.Fatalf("expected second Tj operand to be a byte string, got %+v", tjOps[1].Operands[0])
+    }
+    _, runes := f2.decodeOperand(s)
+    if got := string(runes); got != "hello" {
+        t.Fatalf("expected F2 run text %q to survive the font switch intact, got %q", "hello", got)
+    }
+}
+
+func TestSanitizeBDC_StripsActualText(t *testing.T) {
+    stream := []byte("/Span << /ActualText (SSN: 123-45-6789) >> BDC EMC")
+    ops, err := parseContentStream(stream)
+    if err != nil {
+        t.Fatalf("parseContentStream: %v", err)
+    }
+    if len(ops) == 0 || ops[0].Operator != "BDC" {
+        t.Fatalf("expected first op to be BDC, got: %+v", ops)
+    }
+
+    out := sanitizeBDC(ops[0], ssnBlackboxRule())
+    dict, ok := out.Operands[1].(csDict)
+    if !ok {
+        t.Fatalf("expected properties operand to remain a dict")
+    }
+    val, ok := dict.get("ActualText")
+    if !ok {
+        t.Fatalf("expected ActualText entry to survive sanitization")
+    }
+    b, ok := val.([]byte)
+    if !ok {
+        t.Fatalf("expected ActualText value to be a string")
+    }
+    if strings.Contains(string(b), "123-45-6789") {
+        t.Fatalf("expected SSN to be stripped from ActualText, got: %q", b)
+    }
+}
@@ -12,7 +12,16 @@ import (
 
     "github.com/pdfcpu/pdfcpu/pkg/api"
     "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
-    "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Redaction modes a ReplacementRule can run in. "replace" substitutes the
+// matched text in place; "blackbox" and "whiteout" instead paint an
+// opaque rectangle over the matched glyphs and strip the underlying text
+// so it can't be recovered by copy-paste.
+const (
+    ModeReplace  = "replace"
+    ModeBlackbox = "blackbox"
+    ModeWhiteout = "whiteout"
 )
 
 type ReplacementRule struct {
@@ -20,6 +29,12 @@ type ReplacementRule struct {
     Replace        string `json:"replace"`
     Regex          bool   `json:"regex"`
     CaseInsensitive bool   `json:"caseInsensitive"`
+    Mode           string `json:"mode"`
+    // Validator, when set, is consulted for every regex match before it's
+    // redacted: a match that merely fits the pattern's shape (e.g. 16
+    // digits) but fails a real checksum is left alone. Built by presets;
+    // user-authored rules from JSON config never set it.
+    Validator func(match string) bool `json:"-"`
 }
 
 type ConfigReplacementRule struct {
@@ -27,6 +42,16 @@ type ConfigReplacementRule struct {
     Replace        string      `json:"replace"`
     Regex          bool        `json:"regex"`
     CaseInsensitive bool        `json:"caseInsensitive"`
+    Mode           string      `json:"mode"` // "replace" (default), "blackbox", "whiteout"
+}
+
+func normalizeMode(mode string) string {
+    switch mode {
+    case ModeBlackbox, ModeWhiteout:
+        return mode
+    default:
+        return ModeReplace
+    }
 }
 
 type Config struct {
@@ -35,24 +60,46 @@ type Config struct {
         Preserve bool `json:"preserve"`
         Level    int  `json:"level"`
     } `json:"compression"`
+    Sanitize SanitizeConfig `json:"sanitize"`
+    Presets  []string       `json:"presets"`
+    OCR      OCRConfig      `json:"ocr"`
 }
 
 type PDFRedactor struct {
     replacements []ReplacementRule
     config       *model.Configuration
+    sanitize     SanitizeConfig
+    ocr          OCRConfig
 }
 
 func NewPDFRedactor() *PDFRedactor {
     // Create default configuration with compression enabled
     conf := model.NewDefaultConfiguration()
     conf.DecodeAllStreams = true  // Decode compressed streams for processing
-    conf.CompressStreams = true   // Re-compress on output
+    conf.WriteObjectStream = true // Re-compress on output
+    conf.WriteXRefStream = true
     
     return &PDFRedactor{
         config: conf,
     }
 }
 
+// Clone returns an independent PDFRedactor with a copy of r's rules and
+// configuration, so a single loaded config can be handed to a pool of
+// worker goroutines (see the `watch` subcommand) without them racing on
+// shared state.
+func (r *PDFRedactor) Clone() *PDFRedactor {
+    replacements := make([]ReplacementRule, len(r.replacements))
+    copy(replacements, r.replacements)
+    configCopy := *r.config
+    return &PDFRedactor{
+        replacements: replacements,
+        config:       &configCopy,
+        sanitize:     r.sanitize,
+        ocr:          r.ocr,
+    }
+}
+
 func (r *PDFRedactor) SetCompressionLevel(level int) {
     // pdfcpu uses optimization levels 0-4
     if level >= 0 && level <= 4 {
@@ -66,6 +113,7 @@ func (r *PDFRedactor) AddReplacement(find, replace string, isRegex bool) {
         Replace:        replace,
         Regex:          isRegex,
         CaseInsensitive: false,
+        Mode:           ModeReplace,
     })
 }
 
@@ -75,6 +123,17 @@ func (r *PDFRedactor) AddReplacementWithCase(find, replace string, isRegex, case
         Replace:        replace,
         Regex:          isRegex,
         CaseInsensitive: caseInsensitive,
+        Mode:           ModeReplace,
+    })
+}
+
+func (r *PDFRedactor) AddReplacementWithMode(find, replace string, isRegex, caseInsensitive bool, mode string) {
+    r.replacements = append(r.replacements, ReplacementRule{
+        Find:           find,
+        Replace:        replace,
+        Regex:          isRegex,
+        CaseInsensitive: caseInsensitive,
+        Mode:           normalizeMode(mode),
     })
 }
 
@@ -112,16 +171,28 @@ func (r *PDFRedactor) LoadConfig(configPath string) error {
         
         // Create replacement rule for each pattern
         for _, pattern := range findPatterns {
-            r.AddReplacementWithCase(pattern, configRule.Replace, configRule.Regex, configRule.CaseInsensitive)
+            r.AddReplacementWithMode(pattern, configRule.Replace, configRule.Regex, configRule.CaseInsensitive, configRule.Mode)
         }
     }
-    
+
+    for _, preset := range config.Presets {
+        rules, err := expandPreset(preset)
+        if err != nil {
+            return err
+        }
+        r.replacements = append(r.replacements, rules...)
+    }
+
     // Apply compression settings
     if !config.Compression.Preserve {
-        r.config.CompressStreams = false
+        r.config.WriteObjectStream = false
+        r.config.WriteXRefStream = false
     }
     r.SetCompressionLevel(config.Compression.Level)
-    
+
+    r.sanitize = config.Sanitize
+    r.ocr = config.OCR
+
     return nil
 }
 
@@ -135,7 +206,16 @@ func (r *PDFRedactor) ProcessText(text string) string {
                 pattern = "(?i)" + pattern
             }
             re := regexp.MustCompile(pattern)
-            result = re.ReplaceAllString(result, rule.Replace)
+            if rule.Validator != nil {
+                result = re.ReplaceAllStringFunc(result, func(match string) string {
+                    if rule.Validator(match) {
+                        return rule.Replace
+                    }
+                    return match
+                })
+            } else {
+                result = re.ReplaceAllString(result, rule.Replace)
+            }
         } else {
             if rule.CaseInsensitive {
                 // Case insensitive string replacement
@@ -175,67 +255,157 @@ func (r *PDFRedactor) ProcessText(text string) string {
     return result
 }
 
+// CountMatchesPerRule reports how many times each rule would fire
+// against text, keyed by the rule's Find pattern. It's used by the
+// `watch` subcommand to log per-rule match counts without re-running
+// the full content-stream redaction just for stats.
+func (r *PDFRedactor) CountMatchesPerRule(text string) map[string]int {
+    counts := map[string]int{}
+    for _, rule := range r.replacements {
+        if n := countRuleMatches(text, rule); n > 0 {
+            counts[rule.Find] += n
+        }
+    }
+    return counts
+}
+
+func countRuleMatches(text string, rule ReplacementRule) int {
+    if rule.Regex {
+        pattern := rule.Find
+        if rule.CaseInsensitive {
+            pattern = "(?i)" + pattern
+        }
+        matches := regexp.MustCompile(pattern).FindAllString(text, -1)
+        if rule.Validator == nil {
+            return len(matches)
+        }
+        n := 0
+        for _, m := range matches {
+            if rule.Validator(m) {
+                n++
+            }
+        }
+        return n
+    }
+
+    hay, needle := text, rule.Find
+    if rule.CaseInsensitive {
+        hay = strings.ToLower(hay)
+        needle = strings.ToLower(needle)
+    }
+    if needle == "" {
+        return 0
+    }
+    return strings.Count(hay, needle)
+}
+
+// infoFile reports summary metadata for the PDF at path, rendered as
+// JSON since api.PDFInfo's result has no human-readable String() form.
+func infoFile(path string, conf *model.Configuration) (string, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return "", err
+    }
+    defer f.Close()
+
+    info, err := api.PDFInfo(f, path, nil, false, conf)
+    if err != nil {
+        return "", err
+    }
+    b, err := json.MarshalIndent(info, "", "  ")
+    if err != nil {
+        return "", err
+    }
+    return string(b), nil
+}
+
 func (r *PDFRedactor) RedactPDF(inputPath, outputPath string) error {
     fmt.Printf("Processing: %s\n", inputPath)
-    
+
     // Validate the PDF
     if err := api.ValidateFile(inputPath, r.config); err != nil {
         return fmt.Errorf("validation error: %v", err)
     }
-    
+
     // Get PDF info (including compression status)
-    info, err := api.InfoFile(inputPath, nil, r.config)
+    info, err := infoFile(inputPath, r.config)
     if err != nil {
         return fmt.Errorf("info error: %v", err)
     }
-    
+
     fmt.Printf("PDF Info: %s\n", info)
-    
-    // Extract text to check what needs redaction
-    text, err := api.ExtractTextFile(inputPath, nil, r.config)
+
+    ctx, err := api.ReadContextFile(inputPath)
     if err != nil {
-        return fmt.Errorf("text extraction error: %v", err)
+        return fmt.Errorf("read error: %v", err)
     }
-    
-    // Process the text
-    processedText := r.ProcessText(text)
-    if processedText != text {
-        fmt.Println("Text replacements needed")
-        // Note: Actual text replacement in pdfcpu requires more complex operations
-        // This would involve parsing the content streams and replacing text operators
+
+    matches, err := r.redactText(ctx)
+    if err != nil {
+        return fmt.Errorf("redaction error: %v", err)
     }
-    
-    // For now, optimize the PDF (which handles compression)
-    if err := api.OptimizeFile(inputPath, outputPath, r.config); err != nil {
+    fmt.Printf("Replaced text in %d run(s)\n", matches)
+
+    report, err := r.Sanitize(ctx, r.sanitize)
+    if err != nil {
+        return fmt.Errorf("sanitize error: %v", err)
+    }
+    printSanitizeReport(report)
+
+    ocrMatches, err := r.redactScannedPages(ctx, r.ocr)
+    if err != nil {
+        return fmt.Errorf("ocr error: %v", err)
+    }
+    if ocrMatches > 0 {
+        fmt.Printf("OCR fallback redacted %d word(s) on scanned page(s)\n", ocrMatches)
+    }
+
+    if err := api.WriteContextFile(ctx, outputPath); err != nil {
+        return fmt.Errorf("write error: %v", err)
+    }
+
+    // Re-optimize the already-redacted output in place (handles compression).
+    if err := api.OptimizeFile(outputPath, outputPath, r.config); err != nil {
         return fmt.Errorf("optimization error: %v", err)
     }
-    
+
     // Get output file info
     outputInfo, _ := os.Stat(outputPath)
     inputInfo, _ := os.Stat(inputPath)
-    
+
     fmt.Printf("Original size: %d bytes\n", inputInfo.Size())
-    fmt.Printf("Final size: %d bytes (%.1f%%)\n", 
-        outputInfo.Size(), 
+    fmt.Printf("Final size: %d bytes (%.1f%%)\n",
+        outputInfo.Size(),
         float64(outputInfo.Size())/float64(inputInfo.Size())*100)
-    
+
     fmt.Printf("Successfully created: %s\n", outputPath)
     return nil
 }
 
 func main() {
+    if len(os.Args) > 1 && os.Args[1] == "watch" {
+        runWatch(os.Args[2:])
+        return
+    }
+
     var (
-        find       = flag.String("find", "", "Text to find")
-        replace    = flag.String("replace", "", "Replacement text")
-        isRegex    = flag.Bool("regex", false, "Use regular expression")
-        config     = flag.String("config", "", "Configuration file")
-        noCompress = flag.Bool("no-compress", false, "Disable compression")
-        info       = flag.Bool("info", false, "Show PDF info and exit")
+        find         = flag.String("find", "", "Text to find")
+        replace      = flag.String("replace", "", "Replacement text")
+        isRegex      = flag.Bool("regex", false, "Use regular expression")
+        mode         = flag.String("mode", ModeReplace, "Redaction mode for -find/-replace: replace, blackbox, whiteout")
+        config       = flag.String("config", "", "Configuration file")
+        noCompress   = flag.Bool("no-compress", false, "Disable compression")
+        info         = flag.Bool("info", false, "Show PDF info and exit")
+        sanitize     = flag.String("sanitize", "", "Sanitize shortcut: \"all\" scrubs Info/XMP/annotations/forms/embedded files/JavaScript")
+        presets      = flag.String("presets", "", "Comma-separated built-in PII presets: us-ssn,credit-card,email,us-phone,iban,ipv4,ipv6,aws-key")
+        ocr          = flag.Bool("ocr", false, "Fall back to OCR on pages with little or no extractable text (scanned/image-only PDFs)")
+        ocrEngine    = flag.String("ocr-engine", "tesseract", "OCR engine: tesseract, noop, or a name registered via RegisterOCREngine")
+        ocrThreshold = flag.Float64("ocr-threshold", 1, "Chars of extracted text per square inch below which a page is treated as scanned")
     )
     flag.Parse()
 
     if flag.NArg() < 1 {
-        log.Fatal("Usage: redactor [options] input.pdf [output.pdf]")
+        log.Fatal("Usage: redactor [options] input.pdf [output.pdf]\n       redactor watch -input dir -output dir [options]")
     }
 
     inputPath := flag.Arg(0)
@@ -244,12 +414,13 @@ func main() {
     
     // Handle compression settings
     if *noCompress {
-        redactor.config.CompressStreams = false
+        redactor.config.WriteObjectStream = false
+        redactor.config.WriteXRefStream = false
     }
 
     // Info mode
     if *info {
-        info, err := api.InfoFile(inputPath, nil, redactor.config)
+        info, err := infoFile(inputPath, redactor.config)
         if err != nil {
             log.Fatalf("Error getting info: %v", err)
         }
@@ -269,8 +440,26 @@ func main() {
         }
     }
 
-    if *find != "" && *replace != "" {
-        redactor.AddReplacement(*find, *replace, *isRegex)
+    if *find != "" {
+        redactor.AddReplacementWithMode(*find, *replace, *isRegex, false, *mode)
+    }
+
+    if *sanitize == "all" {
+        redactor.sanitize = SanitizeAll()
+    }
+
+    if *presets != "" {
+        for _, name := range strings.Split(*presets, ",") {
+            rules, err := expandPreset(strings.TrimSpace(name))
+            if err != nil {
+                log.Fatalf("Error loading preset: %v", err)
+            }
+            redactor.replacements = append(redactor.replacements, rules...)
+        }
+    }
+
+    if *ocr {
+        redactor.ocr = OCRConfig{Enabled: true, Engine: *ocrEngine, Threshold: *ocrThreshold}
     }
 
     if err := redactor.RedactPDF(inputPath, outputPath); err != nil {
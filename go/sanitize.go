@@ -0,0 +1,431 @@
+package main
+
+import (
+    "fmt"
+
+    "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+    "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Form sanitization strategies for AcroForm field values/appearances.
+const (
+    FormsClear  = "clear"
+    FormsRedact = "redact"
+)
+
+// SanitizeConfig controls which non-page-content parts of a PDF get
+// scrubbed. Info/XMP/Annotations are on/off: when enabled the content is
+// wholesale cleared (Info, XMP) or run through the configured
+// ReplacementRules (Annotations). Forms/EmbeddedFiles/JavaScript take an
+// explicit strategy since "clear" and "redact" behave differently enough
+// that a bool would hide what actually happens.
+type SanitizeConfig struct {
+    Info          bool   `json:"info"`
+    XMP           bool   `json:"xmp"`
+    Annotations   bool   `json:"annotations"`
+    Forms         string `json:"forms"`         // "clear" or "redact"
+    EmbeddedFiles string `json:"embeddedFiles"`  // "strip"
+    JavaScript    string `json:"javascript"`     // "strip"
+}
+
+// SanitizeAll returns a SanitizeConfig with every subsystem enabled, the
+// behavior behind the `--sanitize=all` CLI shortcut.
+func SanitizeAll() SanitizeConfig {
+    return SanitizeConfig{
+        Info:          true,
+        XMP:           true,
+        Annotations:   true,
+        Forms:         FormsClear,
+        EmbeddedFiles: "strip",
+        JavaScript:    "strip",
+    }
+}
+
+// SanitizeReport records what Sanitize actually touched, so callers can
+// show the user (or downstream tooling) what was removed.
+type SanitizeReport struct {
+    InfoFieldsCleared    []string `json:"infoFieldsCleared,omitempty"`
+    XMPRemoved           bool     `json:"xmpRemoved,omitempty"`
+    AnnotationsRedacted  int      `json:"annotationsRedacted,omitempty"`
+    FormFieldsSanitized  int      `json:"formFieldsSanitized,omitempty"`
+    EmbeddedFilesRemoved []string `json:"embeddedFilesRemoved,omitempty"`
+    JavaScriptRemoved    int      `json:"javaScriptRemoved,omitempty"`
+    NamedDestsSanitized  int      `json:"namedDestsSanitized,omitempty"`
+}
+
+var infoDictFields = []string{"Author", "Title", "Subject", "Keywords", "Creator", "Producer"}
+
+// Sanitize walks the non-page-content parts of ctx according to cfg,
+// applying r.replacements where the config calls for redaction rather
+// than wholesale removal. Named destination names are always run through
+// r.replacements, whatever cfg says.
+func (r *PDFRedactor) Sanitize(ctx *model.Context, cfg SanitizeConfig) (*SanitizeReport, error) {
+    report := &SanitizeReport{}
+    xRefTable := ctx.XRefTable
+
+    if cfg.Info {
+        if err := r.sanitizeInfo(xRefTable, report); err != nil {
+            return report, err
+        }
+    }
+    if cfg.XMP {
+        if err := r.sanitizeXMP(xRefTable, report); err != nil {
+            return report, err
+        }
+    }
+    if cfg.Annotations {
+        if err := r.sanitizeAnnotations(xRefTable, report); err != nil {
+            return report, err
+        }
+    }
+    if cfg.Forms == FormsClear || cfg.Forms == FormsRedact {
+        if err := r.sanitizeForms(xRefTable, cfg.Forms, report); err != nil {
+            return report, err
+        }
+    }
+    if cfg.EmbeddedFiles == "strip" {
+        if err := r.sanitizeEmbeddedFiles(xRefTable, report); err != nil {
+            return report, err
+        }
+    }
+    if cfg.JavaScript == "strip" {
+        if err := r.sanitizeJavaScript(xRefTable, report); err != nil {
+            return report, err
+        }
+    }
+    if err := r.sanitizeNamedDests(xRefTable, report); err != nil {
+        return report, err
+    }
+
+    return report, nil
+}
+
+func (r *PDFRedactor) sanitizeInfo(xRefTable *model.XRefTable, report *SanitizeReport) error {
+    if xRefTable.Info == nil {
+        return nil
+    }
+    infoDict, err := xRefTable.DereferenceDict(*xRefTable.Info)
+    if err != nil || infoDict == nil {
+        return err
+    }
+    for _, key := range infoDictFields {
+        if _, found := infoDict[key]; found {
+            delete(infoDict, key)
+            report.InfoFieldsCleared = append(report.InfoFieldsCleared, key)
+        }
+    }
+    return nil
+}
+
+func (r *PDFRedactor) sanitizeXMP(xRefTable *model.XRefTable, report *SanitizeReport) error {
+    rootDict, err := xRefTable.Catalog()
+    if err != nil || rootDict == nil {
+        return err
+    }
+    if _, found := rootDict["Metadata"]; !found {
+        return nil
+    }
+    delete(rootDict, "Metadata")
+    report.XMPRemoved = true
+    return nil
+}
+
+func (r *PDFRedactor) sanitizeAnnotations(xRefTable *model.XRefTable, report *SanitizeReport) error {
+    for pageNr := 1; pageNr <= xRefTable.PageCount; pageNr++ {
+        pageDict, _, _, err := xRefTable.PageDict(pageNr, false)
+        if err != nil || pageDict == nil {
+            continue
+        }
+        annots, err := xRefTable.DereferenceArray(pageDict["Annots"])
+        if err != nil {
+            continue
+        }
+        for _, a := range annots {
+            annotDict, err := xRefTable.DereferenceDict(a)
+            if err != nil || annotDict == nil {
+                continue
+            }
+            if r.sanitizeAnnotationFields(annotDict) {
+                report.AnnotationsRedacted++
+            }
+        }
+    }
+    return nil
+}
+
+// sanitizeAnnotationFields applies r.replacements to the free-text
+// fields found on highlight/comment/free-text annotations: /Contents
+// (the comment body), /T (author/title), and /RC (rich text contents).
+func (r *PDFRedactor) sanitizeAnnotationFields(annotDict types.Dict) bool {
+    changed := false
+    for _, key := range []string{"Contents", "T", "RC"} {
+        s := annotDict.StringEntry(key)
+        if s == nil || *s == "" {
+            continue
+        }
+        redacted := r.ProcessText(*s)
+        if redacted != *s {
+            annotDict[key] = types.StringLiteral(redacted)
+            changed = true
+        }
+    }
+    return changed
+}
+
+func (r *PDFRedactor) sanitizeForms(xRefTable *model.XRefTable, strategy string, report *SanitizeReport) error {
+    rootDict, err := xRefTable.Catalog()
+    if err != nil || rootDict == nil {
+        return err
+    }
+    acroForm, err := xRefTable.DereferenceDict(rootDict["AcroForm"])
+    if err != nil || acroForm == nil {
+        return nil
+    }
+    fields, err := xRefTable.DereferenceArray(acroForm["Fields"])
+    if err != nil {
+        return nil
+    }
+    for _, f := range fields {
+        if r.sanitizeFormFieldTree(xRefTable, f, strategy) {
+            report.FormFieldsSanitized++
+        }
+    }
+    return nil
+}
+
+// sanitizeFormFieldTree handles one field, recursing into /Kids for
+// hierarchical (radio/checkbox group) fields.
+func (r *PDFRedactor) sanitizeFormFieldTree(xRefTable *model.XRefTable, ref types.Object, strategy string) bool {
+    fieldDict, err := xRefTable.DereferenceDict(ref)
+    if err != nil || fieldDict == nil {
+        return false
+    }
+
+    changed := false
+    if strategy == FormsClear {
+        if _, found := fieldDict["V"]; found {
+            fieldDict["V"] = types.StringLiteral("")
+            changed = true
+        }
+        if _, found := fieldDict["AP"]; found {
+            delete(fieldDict, "AP")
+            changed = true
+        }
+    } else {
+        if v := fieldDict.StringEntry("V"); v != nil && *v != "" {
+            redacted := r.ProcessText(*v)
+            if redacted != *v {
+                fieldDict["V"] = types.StringLiteral(redacted)
+                changed = true
+            }
+        }
+        if r.sanitizeFieldAppearance(xRefTable, fieldDict) {
+            changed = true
+        }
+    }
+
+    if kids, err := xRefTable.DereferenceArray(fieldDict["Kids"]); err == nil {
+        for _, kid := range kids {
+            if r.sanitizeFormFieldTree(xRefTable, kid, strategy) {
+                changed = true
+            }
+        }
+    }
+
+    return changed
+}
+
+// sanitizeFieldAppearance redacts the text drawn in a field's normal (/N)
+// appearance stream, reusing the same content-stream engine as page text
+// so a "redact" field keeps its widget box but shows redacted text.
+func (r *PDFRedactor) sanitizeFieldAppearance(xRefTable *model.XRefTable, fieldDict types.Dict) bool {
+    apDict, err := xRefTable.DereferenceDict(fieldDict["AP"])
+    if err != nil || apDict == nil {
+        return false
+    }
+    ref := apDict["N"]
+    nStream, _, err := xRefTable.DereferenceStreamDict(ref)
+    if err != nil || nStream == nil {
+        return false
+    }
+    if err := nStream.Decode(); err != nil {
+        return false
+    }
+    fonts, err := resourceFonts(xRefTable, fieldDict["DR"])
+    if err != nil {
+        fonts = map[string]*GlyphFont{}
+    }
+    rewritten, n, err := redactContentStream(nStream.Content, fonts, r.replacements)
+    if err != nil || n == 0 {
+        return false
+    }
+    nStream.Content = rewritten
+    if err := nStream.Encode(); err != nil {
+        return false
+    }
+    indRef, ok := ref.(types.IndirectRef)
+    if !ok {
+        return false
+    }
+    entry, ok := xRefTable.FindTableEntryForIndRef(&indRef)
+    if !ok {
+        return false
+    }
+    entry.Object = *nStream
+    return true
+}
+
+func (r *PDFRedactor) sanitizeEmbeddedFiles(xRefTable *model.XRefTable, report *SanitizeReport) error {
+    rootDict, err := xRefTable.Catalog()
+    if err != nil || rootDict == nil {
+        return err
+    }
+    names, err := xRefTable.DereferenceDict(rootDict["Names"])
+    if err != nil || names == nil {
+        return nil
+    }
+    if _, found := names["EmbeddedFiles"]; !found {
+        return nil
+    }
+    efTree, err := xRefTable.DereferenceDict(names["EmbeddedFiles"])
+    if err == nil && efTree != nil {
+        for _, name := range namesInNameTree(xRefTable, efTree) {
+            report.EmbeddedFilesRemoved = append(report.EmbeddedFilesRemoved, name)
+        }
+    }
+    delete(names, "EmbeddedFiles")
+    return nil
+}
+
+func (r *PDFRedactor) sanitizeJavaScript(xRefTable *model.XRefTable, report *SanitizeReport) error {
+    rootDict, err := xRefTable.Catalog()
+    if err != nil || rootDict == nil {
+        return err
+    }
+
+    if names, err := xRefTable.DereferenceDict(rootDict["Names"]); err == nil && names != nil {
+        if _, found := names["JavaScript"]; found {
+            if jsTree, err := xRefTable.DereferenceDict(names["JavaScript"]); err == nil && jsTree != nil {
+                report.JavaScriptRemoved += len(namesInNameTree(xRefTable, jsTree))
+            }
+            delete(names, "JavaScript")
+        }
+    }
+
+    if openAction, err := xRefTable.DereferenceDict(rootDict["OpenAction"]); err == nil && openAction != nil {
+        if s := openAction.NameEntry("S"); s != nil && *s == "JavaScript" {
+            delete(rootDict, "OpenAction")
+            report.JavaScriptRemoved++
+        }
+    }
+
+    return nil
+}
+
+// sanitizeNamedDests applies r.replacements to the document-level named
+// destination tree's own key strings. The destination values themselves
+// are positional arrays ([page /Fit ...]) with nothing to redact, but the
+// names are free-form text chosen by whoever authored the bookmark/link
+// (e.g. "Account-123-45-6789") and can carry the same PII as any other
+// string in the document.
+func (r *PDFRedactor) sanitizeNamedDests(xRefTable *model.XRefTable, report *SanitizeReport) error {
+    rootDict, err := xRefTable.Catalog()
+    if err != nil || rootDict == nil {
+        return err
+    }
+    names, err := xRefTable.DereferenceDict(rootDict["Names"])
+    if err != nil || names == nil {
+        return nil
+    }
+    destTree, err := xRefTable.DereferenceDict(names["Dests"])
+    if err != nil || destTree == nil {
+        return nil
+    }
+    n, err := r.redactNameTreeKeys(xRefTable, destTree)
+    if err != nil {
+        return err
+    }
+    report.NamedDestsSanitized += n
+    return nil
+}
+
+// redactNameTreeKeys rewrites a name tree's leaf key strings in place by
+// applying r.replacements, recursing through /Kids. It returns how many
+// keys were changed.
+func (r *PDFRedactor) redactNameTreeKeys(xRefTable *model.XRefTable, node types.Dict) (int, error) {
+    changed := 0
+    if arr, err := xRefTable.DereferenceArray(node["Names"]); err == nil {
+        for i := 0; i+1 < len(arr); i += 2 {
+            name, ok := arr[i].(types.StringLiteral)
+            if !ok {
+                continue
+            }
+            redacted := r.ProcessText(string(name))
+            if redacted != string(name) {
+                arr[i] = types.StringLiteral(redacted)
+                changed++
+            }
+        }
+    }
+    if kids, err := xRefTable.DereferenceArray(node["Kids"]); err == nil {
+        for _, k := range kids {
+            kidDict, err := xRefTable.DereferenceDict(k)
+            if err != nil || kidDict == nil {
+                continue
+            }
+            n, err := r.redactNameTreeKeys(xRefTable, kidDict)
+            if err != nil {
+                return changed, err
+            }
+            changed += n
+        }
+    }
+    return changed, nil
+}
+
+// printSanitizeReport prints a short summary of what Sanitize removed,
+// in the same terse style as RedactPDF's other progress output.
+func printSanitizeReport(report *SanitizeReport) {
+    if len(report.InfoFieldsCleared) > 0 {
+        fmt.Printf("Cleared Info fields: %v\n", report.InfoFieldsCleared)
+    }
+    if report.XMPRemoved {
+        fmt.Println("Removed XMP metadata stream")
+    }
+    if report.AnnotationsRedacted > 0 {
+        fmt.Printf("Redacted %d annotation(s)\n", report.AnnotationsRedacted)
+    }
+    if report.FormFieldsSanitized > 0 {
+        fmt.Printf("Sanitized %d form field(s)\n", report.FormFieldsSanitized)
+    }
+    if len(report.EmbeddedFilesRemoved) > 0 {
+        fmt.Printf("Removed embedded files: %v\n", report.EmbeddedFilesRemoved)
+    }
+    if report.JavaScriptRemoved > 0 {
+        fmt.Printf("Removed %d JavaScript action(s)\n", report.JavaScriptRemoved)
+    }
+    if report.NamedDestsSanitized > 0 {
+        fmt.Printf("Redacted %d named destination name(s)\n", report.NamedDestsSanitized)
+    }
+}
+
+// namesInNameTree returns the leaf names of a PDF name tree (the odd
+// entries of each /Names array, walked recursively through /Kids).
+func namesInNameTree(xRefTable *model.XRefTable, node types.Dict) []string {
+    var out []string
+    if arr, err := xRefTable.DereferenceArray(node["Names"]); err == nil {
+        for i := 0; i+1 < len(arr); i += 2 {
+            if name, ok := arr[i].(types.StringLiteral); ok {
+                out = append(out, string(name))
+            }
+        }
+    }
+    if kids, err := xRefTable.DereferenceArray(node["Kids"]); err == nil {
+        for _, k := range kids {
+            if kidDict, err := xRefTable.DereferenceDict(k); err == nil && kidDict != nil {
+                out = append(out, namesInNameTree(xRefTable, kidDict)...)
+            }
+        }
+    }
+    return out
+}
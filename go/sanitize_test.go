@@ -0,0 +1,101 @@
+package main
+
+import (
+    "testing"
+
+    "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+func ssnReplaceRule() []ReplacementRule {
+    return []ReplacementRule{
+        {Find: `\d{3}-\d{2}-\d{4}`, Regex: true, Replace: "[SSN]", Mode: ModeReplace},
+    }
+}
+
+func TestSanitizeAnnotationFields_RedactsContentsAndTitle(t *testing.T) {
+    r := &PDFRedactor{replacements: ssnReplaceRule()}
+    annot := types.Dict{
+        "Contents": types.StringLiteral("Borrower SSN: 123-45-6789"),
+        "T":        types.StringLiteral("Reviewer 123-45-6789"),
+    }
+
+    if !r.sanitizeAnnotationFields(annot) {
+        t.Fatal("expected sanitizeAnnotationFields to report a change")
+    }
+    if s := annot.StringEntry("Contents"); s == nil || *s != "Borrower SSN: [SSN]" {
+        t.Errorf("Contents not redacted, got %v", annot["Contents"])
+    }
+    if s := annot.StringEntry("T"); s == nil || *s != "Reviewer [SSN]" {
+        t.Errorf("T not redacted, got %v", annot["T"])
+    }
+}
+
+func TestSanitizeAnnotationFields_NoMatchLeavesFieldsAlone(t *testing.T) {
+    r := &PDFRedactor{replacements: ssnReplaceRule()}
+    annot := types.Dict{"Contents": types.StringLiteral("no PII here")}
+
+    if r.sanitizeAnnotationFields(annot) {
+        t.Fatal("expected no change when no rule matches")
+    }
+    if s := annot.StringEntry("Contents"); s == nil || *s != "no PII here" {
+        t.Errorf("Contents unexpectedly modified: %v", annot["Contents"])
+    }
+}
+
+func TestSanitizeFormFieldTree_ClearStrategyBlanksValueAndDropsAppearance(t *testing.T) {
+    r := &PDFRedactor{}
+    field := types.Dict{
+        "V":  types.StringLiteral("123-45-6789"),
+        "AP": types.Dict{"N": types.StringLiteral("some appearance stream ref")},
+    }
+
+    if !r.sanitizeFormFieldTree(nil, field, FormsClear) {
+        t.Fatal("expected sanitizeFormFieldTree to report a change")
+    }
+    if v := field.StringEntry("V"); v == nil || *v != "" {
+        t.Errorf("expected V to be blanked, got %v", field["V"])
+    }
+    if _, found := field["AP"]; found {
+        t.Errorf("expected AP to be removed under the clear strategy, got %v", field["AP"])
+    }
+}
+
+func TestSanitizeFormFieldTree_RedactStrategyAppliesRules(t *testing.T) {
+    r := &PDFRedactor{replacements: ssnReplaceRule()}
+    field := types.Dict{"V": types.StringLiteral("SSN 123-45-6789")}
+
+    if !r.sanitizeFormFieldTree(nil, field, FormsRedact) {
+        t.Fatal("expected sanitizeFormFieldTree to report a change")
+    }
+    if v := field.StringEntry("V"); v == nil || *v != "SSN [SSN]" {
+        t.Errorf("expected V to be redacted, got %v", field["V"])
+    }
+}
+
+func TestRedactNameTreeKeys_RedactsDestinationNames(t *testing.T) {
+    r := &PDFRedactor{replacements: ssnReplaceRule()}
+    destTree := types.Dict{
+        "Names": types.Array{
+            types.StringLiteral("Account-123-45-6789"),
+            types.Array{types.Integer(0), types.Name("Fit")},
+            types.StringLiteral("Summary"),
+            types.Array{types.Integer(1), types.Name("Fit")},
+        },
+    }
+
+    n, err := r.redactNameTreeKeys(nil, destTree)
+    if err != nil {
+        t.Fatalf("redactNameTreeKeys: %v", err)
+    }
+    if n != 1 {
+        t.Fatalf("expected 1 redacted name, got %d", n)
+    }
+
+    arr := destTree["Names"].(types.Array)
+    if got, ok := arr[0].(types.StringLiteral); !ok || string(got) != "Account-[SSN]" {
+        t.Errorf("expected first destination name redacted, got %v", arr[0])
+    }
+    if got, ok := arr[2].(types.StringLiteral); !ok || string(got) != "Summary" {
+        t.Errorf("expected second destination name untouched, got %v", arr[2])
+    }
+}
@@ -0,0 +1,530 @@
+package main
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+    "unicode/utf16"
+    "unicode/utf8"
+)
+
+// textRun is one contiguous run of show-text operators between two
+// position-setting ops (BT, Td, TD, T*, Tm), recorded so a match
+// spanning several Tj/TJ operands can be resolved against the
+// concatenated text of the whole run before being spliced back in.
+type textRun struct {
+    font      *GlyphFont
+    fontSize  float64
+    tlm       matrix6 // text line matrix at the start of the run
+    leading   float64 // current TL leading when the run started, used to drop an overlong replacement onto the next line
+    sourceOps []csOp  // original Tj/TJ/'/" ops this run replaces
+    codes     []uint32
+    text      string
+    adjWidth  float64 // total width already contributed by TJ numeric (kerning) adjustments in the run, so width math accounts for it
+}
+
+// totalWidth returns the run's original rendered advance: the sum of its
+// glyph widths plus whatever TJ kerning adjustments were already present.
+func (run *textRun) totalWidth() float64 {
+    w := 0.0
+    for _, code := range run.codes {
+        w += run.font.widthOf(code) / 1000 * run.fontSize
+    }
+    return w + run.adjWidth
+}
+
+// rect is a page-space rectangle to paint over a redacted glyph run.
+type rect struct {
+    x, y, w, h float64
+    mode       string
+}
+
+// redactContentStream rewrites Tj/TJ/'/" operators in a page content
+// stream. Rules in "replace" mode substitute the matched text; rules in
+// "blackbox"/"whiteout" mode strip the matched glyphs from the stream
+// and queue an opaque rectangle to be painted over them once the
+// enclosing text object closes. fonts maps a page resource font name
+// (e.g. "F1") to its GlyphFont, as selected by preceding Tf operators.
+func redactContentStream(data []byte, fonts map[string]*GlyphFont, rules []ReplacementRule) ([]byte, int, error) {
+    srcOps, err := parseContentStream(data)
+    if err != nil {
+        return nil, 0, fmt.Errorf("redactContentStream: %v", err)
+    }
+
+    var out []csOp
+    var curFont *GlyphFont
+    var curFontSize float64
+    tlm := identityMatrix()
+    leading := 0.0
+    var run *textRun
+    var pendingRects []rect
+    totalMatches := 0
+
+    flushRun := func() {
+        if run == nil {
+            return
+        }
+        if run.text == "" {
+            out = append(out, run.sourceOps...)
+            run = nil
+            return
+        }
+        ops, rects, changed := processRun(run, rules)
+        if changed {
+            totalMatches++
+            out = append(out, ops...)
+            pendingRects = append(pendingRects, rects...)
+        } else {
+            out = append(out, run.sourceOps...)
+        }
+        run = nil
+    }
+
+    flushBlock := func() {
+        for _, rc := range pendingRects {
+            out = append(out, rectOps(rc)...)
+        }
+        pendingRects = nil
+    }
+
+    for _, op := range srcOps {
+        switch op.Operator {
+        case "BT":
+            tlm = identityMatrix()
+            leading = 0
+            run = nil
+            out = append(out, op)
+        case "ET":
+            flushRun()
+            out = append(out, op)
+            flushBlock()
+        case "TL":
+            if len(op.Operands) == 1 {
+                if v, ok := op.Operands[0].(float64); ok {
+                    leading = v
+                }
+            }
+            out = append(out, op)
+        case "Tf":
+            flushRun()
+            if len(op.Operands) >= 2 {
+                if name, ok := op.Operands[0].(csName); ok {
+                    curFont = fonts[string(name)]
+                }
+                if size, ok := op.Operands[len(op.Operands)-1].(float64); ok {
+                    curFontSize = size
+                }
+            }
+            out = append(out, op)
+        case "Td":
+            flushRun()
+            if len(op.Operands) == 2 {
+                tx, _ := op.Operands[0].(float64)
+                ty, _ := op.Operands[1].(float64)
+                tlm = mulMatrix(translateMatrix(tx, ty), tlm)
+            }
+            out = append(out, op)
+        case "TD":
+            flushRun()
+            if len(op.Operands) == 2 {
+                tx, _ := op.Operands[0].(float64)
+                ty, _ := op.Operands[1].(float64)
+                leading = -ty
+                tlm = mulMatrix(translateMatrix(tx, ty), tlm)
+            }
+            out = append(out, op)
+        case "T*":
+            flushRun()
+            tlm = mulMatrix(translateMatrix(0, -leading), tlm)
+            out = append(out, op)
+        case "Tm":
+            flushRun()
+            if len(op.Operands) == 6 {
+                a, _ := op.Operands[0].(float64)
+                b, _ := op.Operands[1].(float64)
+                c, _ := op.Operands[2].(float64)
+                d, _ := op.Operands[3].(float64)
+                e, _ := op.Operands[4].(float64)
+                f, _ := op.Operands[5].(float64)
+                tlm = matrix6{a: a, b: b, c: c, d: d, e: e, f: f}
+            }
+            out = append(out, op)
+        case "Tj", "'", "\"":
+            if len(op.Operands) == 0 || curFont == nil {
+                out = append(out, op)
+                continue
+            }
+            s, ok := op.Operands[len(op.Operands)-1].([]byte)
+            if !ok {
+                out = append(out, op)
+                continue
+            }
+            run = appendRunText(run, curFont, curFontSize, tlm, leading, op, s)
+        case "TJ":
+            if len(op.Operands) == 0 || curFont == nil {
+                out = append(out, op)
+                continue
+            }
+            arr, ok := op.Operands[0].([]csObject)
+            if !ok {
+                out = append(out, op)
+                continue
+            }
+            if !containsString(arr) {
+                out = append(out, op)
+                continue
+            }
+            if run == nil {
+                run = &textRun{font: curFont, fontSize: curFontSize, tlm: tlm, leading: leading}
+            }
+            for _, e := range arr {
+                if s, ok := e.([]byte); ok {
+                    codes, runes := curFont.decodeOperand(s)
+                    run.codes = append(run.codes, codes...)
+                    run.text += string(runes)
+                } else if n, ok := e.(float64); ok {
+                    // TJ numbers are subtracted from the advance: a positive
+                    // number shrinks it, so the width it contributes is the
+                    // negation.
+                    run.adjWidth += -n / 1000 * run.fontSize
+                }
+            }
+            run.sourceOps = append(run.sourceOps, op)
+        case "BDC":
+            out = append(out, sanitizeBDC(op, rules))
+        default:
+            out = append(out, op)
+        }
+    }
+    flushRun()
+    flushBlock()
+
+    return writeContentStream(out), totalMatches, nil
+}
+
+func containsString(arr []csObject) bool {
+    for _, e := range arr {
+        if _, ok := e.([]byte); ok {
+            return true
+        }
+    }
+    return false
+}
+
+func appendRunText(run *textRun, font *GlyphFont, fontSize float64, tlm matrix6, leading float64, op csOp, s []byte) *textRun {
+    if run == nil {
+        run = &textRun{font: font, fontSize: fontSize, tlm: tlm, leading: leading}
+    }
+    codes, runes := font.decodeOperand(s)
+    run.codes = append(run.codes, codes...)
+    run.text += string(runes)
+    run.sourceOps = append(run.sourceOps, op)
+    return run
+}
+
+// processRun applies visual-mode rules (queuing a rect + blanking the
+// matched glyphs) and then replace-mode rules (substituting text) to a
+// run, in that order. It returns the op(s) that should replace the run's
+// source ops (buildPaddedOp may split a longer replacement across two),
+// the rects to paint once the text object closes, and whether anything
+// actually changed.
+func processRun(run *textRun, rules []ReplacementRule) ([]csOp, []rect, bool) {
+    runes := []rune(run.text)
+    codes := append([]uint32(nil), run.codes...)
+    var rects []rect
+
+    spaceCode, haveSpace := run.font.Encode[' ']
+
+    for _, rule := range rules {
+        if normalizeMode(rule.Mode) == ModeReplace {
+            continue
+        }
+        for _, m := range findMatches(string(runes), rule) {
+            x0 := prefixWidth(run.font, run.codes, m.start, run.fontSize)
+            x1 := prefixWidth(run.font, run.codes, m.end, run.fontSize)
+            rx, ry, rw, rh := axisAlignedBBox(run.tlm, x0, -0.25*run.fontSize, x1, 0.78*run.fontSize)
+            rects = append(rects, rect{x: rx, y: ry, w: rw, h: rh, mode: rule.Mode})
+            for i := m.start; i < m.end && i < len(codes); i++ {
+                runes[i] = ' '
+                // A font with no mapping for ' ' (common in numeric-only
+                // subsets used for account numbers/SSNs) has no safe glyph
+                // code to substitute, so fall back to 0 rather than leaving
+                // the original PII glyph code in the stream.
+                if haveSpace {
+                    codes[i] = spaceCode
+                } else {
+                    codes[i] = 0
+                }
+            }
+        }
+    }
+
+    strippedText := string(runes)
+    finalText := strippedText
+    replaceChanged := false
+    for _, rule := range rules {
+        if normalizeMode(rule.Mode) != ModeReplace {
+            continue
+        }
+        redactor := &PDFRedactor{replacements: []ReplacementRule{rule}}
+        next := redactor.ProcessText(finalText)
+        if next != finalText {
+            replaceChanged = true
+            finalText = next
+        }
+    }
+
+    if len(rects) == 0 && !replaceChanged {
+        return nil, nil, false
+    }
+
+    if !replaceChanged {
+        // Only glyph stripping happened: the code count is unchanged, so
+        // pack the stripped codes back directly and keep the run's total
+        // advance width: glyph widths are unchanged and the run's TJ
+        // kerning total is re-added as one trailing adjustment.
+        packed := run.font.packCodes(codes)
+        if run.adjWidth != 0 && run.fontSize > 0 {
+            array := []csObject{packed, -run.adjWidth / run.fontSize * 1000}
+            return []csOp{{Operator: "TJ", Operands: []csObject{array}}}, rects, true
+        }
+        op := csOp{Operator: "Tj", Operands: []csObject{packed}}
+        return []csOp{op}, rects, true
+    }
+
+    return buildPaddedOp(run, finalText), rects, true
+}
+
+// buildPaddedOp re-encodes finalText with run.font and reconciles its
+// advance against the original run's (including any TJ kerning
+// adjustments the run already carried), so surrounding layout doesn't
+// shift: a narrower replacement is padded with a TJ adjustment, and a
+// wider one is split across two show-text ops, the overflow dropped onto
+// the next line (at the run's leading) instead of overflowing into
+// whatever follows on the line.
+func buildPaddedOp(run *textRun, finalText string) []csOp {
+    origWidth := run.totalWidth()
+
+    newCodes, _ := run.font.encodeRunes(finalText)
+    newWidth := 0.0
+    for _, code := range newCodes {
+        newWidth += run.font.widthOf(code) / 1000 * run.fontSize
+    }
+
+    if run.fontSize <= 0 || newWidth <= origWidth {
+        array := []csObject{run.font.packCodes(newCodes)}
+        if run.fontSize > 0 && newWidth < origWidth {
+            deficitThousandths := (origWidth - newWidth) / run.fontSize * 1000
+            // TJ numbers are subtracted from the advance, so a negative
+            // value pushes the next glyph right, growing the run back to
+            // its original width.
+            array = append(array, -deficitThousandths)
+        }
+        return []csOp{{Operator: "TJ", Operands: []csObject{array}}}
+    }
+
+    // finalText renders wider than the run it's replacing. Emit as many
+    // glyphs as fit in the original width, then drop to the next line for
+    // the remainder rather than let it bleed into whatever comes next.
+    split := len(newCodes)
+    w := 0.0
+    for i, code := range newCodes {
+        cw := run.font.widthOf(code) / 1000 * run.fontSize
+        if w+cw > origWidth {
+            split = i
+            break
+        }
+        w += cw
+    }
+    if split == 0 {
+        split = 1 // always show at least one glyph on the first line
+    }
+
+    leading := run.leading
+    if leading <= 0 {
+        leading = run.fontSize * 1.2 // reasonable single-line-height fallback when TL was never set
+    }
+
+    return []csOp{
+        {Operator: "Tj", Operands: []csObject{run.font.packCodes(newCodes[:split])}},
+        {Operator: "Td", Operands: []csObject{0.0, -leading}},
+        {Operator: "Tj", Operands: []csObject{run.font.packCodes(newCodes[split:])}},
+    }
+}
+
+// prefixWidth returns the text-space advance, scaled by fontSize, of
+// codes[:idx].
+func prefixWidth(font *GlyphFont, codes []uint32, idx int, fontSize float64) float64 {
+    w := 0.0
+    for i := 0; i < idx && i < len(codes); i++ {
+        w += font.widthOf(codes[i]) / 1000 * fontSize
+    }
+    return w
+}
+
+// rectOps renders a rect as an opaque path fill: `q <gray> g x y w h re f Q`.
+func rectOps(rc rect) []csOp {
+    gray := 0.0
+    if rc.mode == ModeWhiteout {
+        gray = 1.0
+    }
+    return []csOp{
+        {Operator: "q"},
+        {Operator: "g", Operands: []csObject{gray}},
+        {Operator: "re", Operands: []csObject{rc.x, rc.y, rc.w, rc.h}},
+        {Operator: "f"},
+        {Operator: "Q"},
+    }
+}
+
+// textMatch is a rune-index range (not byte-index: font codes map 1:1 to
+// decoded runes, so rune indexes are what line up with run.codes).
+type textMatch struct {
+    start, end int
+}
+
+func findMatches(text string, rule ReplacementRule) []textMatch {
+    if rule.Regex {
+        pattern := rule.Find
+        if rule.CaseInsensitive {
+            pattern = "(?i)" + pattern
+        }
+        re := regexp.MustCompile(pattern)
+        var matches []textMatch
+        for _, loc := range re.FindAllStringIndex(text, -1) {
+            if rule.Validator != nil && !rule.Validator(text[loc[0]:loc[1]]) {
+                continue
+            }
+            matches = append(matches, textMatch{
+                start: utf8.RuneCountInString(text[:loc[0]]),
+                end:   utf8.RuneCountInString(text[:loc[1]]),
+            })
+        }
+        return matches
+    }
+
+    hay, needle := text, rule.Find
+    if rule.CaseInsensitive {
+        hay = strings.ToLower(hay)
+        needle = strings.ToLower(needle)
+    }
+    if needle == "" {
+        return nil
+    }
+    var matches []textMatch
+    pos := 0
+    for {
+        idx := strings.Index(hay[pos:], needle)
+        if idx == -1 {
+            return matches
+        }
+        start := pos + idx
+        end := start + len(needle)
+        matches = append(matches, textMatch{
+            start: utf8.RuneCountInString(text[:start]),
+            end:   utf8.RuneCountInString(text[:end]),
+        })
+        pos = end
+    }
+}
+
+// sanitizeBDC strips or substitutes matched PII inside a BDC operator's
+// inline /ActualText marked-content property, so copy-pasting a
+// redacted region can't recover the original text even when the glyphs
+// themselves were only painted over.
+func sanitizeBDC(op csOp, rules []ReplacementRule) csOp {
+    if len(op.Operands) != 2 {
+        return op
+    }
+    dict, ok := op.Operands[1].(csDict)
+    if !ok {
+        return op
+    }
+    raw, ok := dict.get("ActualText")
+    if !ok {
+        return op
+    }
+    b, ok := raw.([]byte)
+    if !ok {
+        return op
+    }
+
+    hadBOM := len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF
+    text := decodeActualText(b, hadBOM)
+    newText, changed := redactActualText(text, rules)
+    if !changed {
+        return op
+    }
+
+    newDict := dict.set("ActualText", encodeActualText(newText, hadBOM))
+    return csOp{Operator: op.Operator, Operands: []csObject{op.Operands[0], newDict}}
+}
+
+// redactActualText strips visual-mode matches outright (ActualText isn't
+// rendered, so there's no layout to preserve) and applies replace-mode
+// rules the same way ProcessText would.
+func redactActualText(text string, rules []ReplacementRule) (string, bool) {
+    changed := false
+    for _, rule := range rules {
+        if normalizeMode(rule.Mode) == ModeReplace {
+            redactor := &PDFRedactor{replacements: []ReplacementRule{rule}}
+            next := redactor.ProcessText(text)
+            if next != text {
+                changed = true
+                text = next
+            }
+            continue
+        }
+
+        matches := findMatches(text, rule)
+        if len(matches) == 0 {
+            continue
+        }
+        runes := []rune(text)
+        var b strings.Builder
+        last := 0
+        for _, m := range matches {
+            b.WriteString(string(runes[last:m.start]))
+            last = m.end
+        }
+        b.WriteString(string(runes[last:]))
+        text = b.String()
+        changed = true
+    }
+    return text, changed
+}
+
+func decodeActualText(b []byte, hadBOM bool) string {
+    if hadBOM {
+        return utf16BEToString(b[2:])
+    }
+    return string(b)
+}
+
+func encodeActualText(s string, hadBOM bool) []byte {
+    if hadBOM {
+        return append([]byte{0xFE, 0xFF}, stringToUTF16BE(s)...)
+    }
+    return []byte(s)
+}
+
+func utf16BEToString(b []byte) string {
+    if len(b)%2 == 1 {
+        b = b[:len(b)-1]
+    }
+    u := make([]uint16, len(b)/2)
+    for i := range u {
+        u[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+    }
+    return string(utf16.Decode(u))
+}
+
+func stringToUTF16BE(s string) []byte {
+    u := utf16.Encode([]rune(s))
+    b := make([]byte, len(u)*2)
+    for i, v := range u {
+        b[2*i] = byte(v >> 8)
+        b[2*i+1] = byte(v)
+    }
+    return b
+}
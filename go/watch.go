@@ -0,0 +1,309 @@
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/fsnotify/fsnotify"
+    "github.com/pdfcpu/pdfcpu/pkg/api"
+    "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// extractTextForStats pulls plain text out of a PDF purely so the log
+// entry can report per-rule match counts; it has no bearing on the
+// actual redaction, which works from the content streams directly.
+// pdfcpu has no built-in text-extraction API, so this walks each page's
+// content stream with the same font-decoding machinery redactContentStream
+// uses.
+func extractTextForStats(redactor *PDFRedactor, inputPath string) (string, error) {
+    ctx, err := api.ReadContextFile(inputPath)
+    if err != nil {
+        return "", err
+    }
+    return extractAllText(ctx.XRefTable)
+}
+
+// extractAllText concatenates the decoded Tj/TJ/'/" operand text from
+// every page's content stream, in page order.
+func extractAllText(xRefTable *model.XRefTable) (string, error) {
+    var out strings.Builder
+    for pageNr := 1; pageNr <= xRefTable.PageCount; pageNr++ {
+        pageDict, _, _, err := xRefTable.PageDict(pageNr, false)
+        if err != nil || pageDict == nil {
+            continue
+        }
+        fonts, err := pageFonts(xRefTable, pageDict)
+        if err != nil {
+            continue
+        }
+        raw, err := pageContentBytes(xRefTable, pageDict)
+        if err != nil {
+            continue
+        }
+        ops, err := parseContentStream(raw)
+        if err != nil {
+            continue
+        }
+
+        var curFont *GlyphFont
+        for _, op := range ops {
+            switch op.Operator {
+            case "Tf":
+                if len(op.Operands) >= 1 {
+                    if name, ok := op.Operands[0].(csName); ok {
+                        curFont = fonts[string(name)]
+                    }
+                }
+            case "Tj", "'", "\"":
+                if curFont == nil || len(op.Operands) == 0 {
+                    continue
+                }
+                if s, ok := op.Operands[len(op.Operands)-1].([]byte); ok {
+                    _, runes := curFont.decodeOperand(s)
+                    out.WriteString(string(runes))
+                }
+            case "TJ":
+                if curFont == nil || len(op.Operands) == 0 {
+                    continue
+                }
+                if arr, ok := op.Operands[0].([]csObject); ok {
+                    for _, e := range arr {
+                        if s, ok := e.([]byte); ok {
+                            _, runes := curFont.decodeOperand(s)
+                            out.WriteString(string(runes))
+                        }
+                    }
+                }
+            }
+        }
+        out.WriteByte('\n')
+    }
+    return out.String(), nil
+}
+
+// watchOptions configures the `watch` subcommand.
+type watchOptions struct {
+    inputDir      string
+    outputDir     string
+    concurrency   int
+    debounce      time.Duration
+    moveProcessed string
+}
+
+// runWatch implements `redactor watch`: monitor inputDir for new/changed
+// PDFs and redact each into outputDir, preserving the relative subpath.
+func runWatch(args []string) {
+    fs := flag.NewFlagSet("watch", flag.ExitOnError)
+    input := fs.String("input", "", "Directory to watch for PDFs")
+    output := fs.String("output", "", "Mirror directory for redacted output")
+    config := fs.String("config", "", "Configuration file")
+    concurrency := fs.Int("concurrency", 4, "Number of worker goroutines")
+    debounce := fs.Duration("debounce", 2*time.Second, "Quiet period before a written file is considered stable")
+    moveProcessed := fs.String("move-processed", "", "Archive directory: successes move here, failures to <dir>/failed")
+    fs.Parse(args)
+
+    if *input == "" || *output == "" {
+        log.Fatal("watch: -input and -output are required")
+    }
+
+    base := NewPDFRedactor()
+    if *config != "" {
+        if err := base.LoadConfig(*config); err != nil {
+            log.Fatalf("watch: error loading config: %v", err)
+        }
+    }
+
+    w := newDirWatcher(watchOptions{
+        inputDir:      *input,
+        outputDir:     *output,
+        concurrency:   *concurrency,
+        debounce:      *debounce,
+        moveProcessed: *moveProcessed,
+    }, base)
+
+    if err := w.run(); err != nil {
+        log.Fatalf("watch: %v", err)
+    }
+}
+
+// logEntry is one structured JSON log line emitted per processed file,
+// so downstream tooling can aggregate match counts without scraping
+// human-readable output.
+type logEntry struct {
+    Timestamp     string         `json:"timestamp"`
+    File          string         `json:"file"`
+    Output        string         `json:"output"`
+    MatchesByRule map[string]int `json:"matchesByRule,omitempty"`
+    Error         string         `json:"error,omitempty"`
+}
+
+// dirWatcher owns the fsnotify watch, the debounce timers, and the
+// worker pool that actually redacts files.
+type dirWatcher struct {
+    opts watchOptions
+    base *PDFRedactor
+
+    jobs chan string
+
+    mu      sync.Mutex
+    pending map[string]*time.Timer
+}
+
+func newDirWatcher(opts watchOptions, base *PDFRedactor) *dirWatcher {
+    return &dirWatcher{
+        opts:    opts,
+        base:    base,
+        jobs:    make(chan string, 256),
+        pending: map[string]*time.Timer{},
+    }
+}
+
+func (w *dirWatcher) run() error {
+    fsw, err := fsnotify.NewWatcher()
+    if err != nil {
+        return fmt.Errorf("creating watcher: %v", err)
+    }
+    defer fsw.Close()
+
+    if err := addRecursive(fsw, w.opts.inputDir); err != nil {
+        return fmt.Errorf("watching %s: %v", w.opts.inputDir, err)
+    }
+
+    var workers sync.WaitGroup
+    for i := 0; i < w.opts.concurrency; i++ {
+        worker := w.base.Clone()
+        workers.Add(1)
+        go func() {
+            defer workers.Done()
+            for path := range w.jobs {
+                w.process(worker, path)
+            }
+        }()
+    }
+
+    fmt.Printf("Watching %s with %d worker(s), writing to %s\n", w.opts.inputDir, w.opts.concurrency, w.opts.outputDir)
+
+    for {
+        select {
+        case event, ok := <-fsw.Events:
+            if !ok {
+                close(w.jobs)
+                workers.Wait()
+                return nil
+            }
+            w.handleEvent(fsw, event)
+        case err, ok := <-fsw.Errors:
+            if !ok {
+                continue
+            }
+            log.Printf("watch: fsnotify error: %v", err)
+        }
+    }
+}
+
+// addRecursive adds dir and every subdirectory to fsw: fsnotify only
+// watches a single directory level, so new subdirectories are picked up
+// as they're created (see handleEvent) and walked in turn.
+func addRecursive(fsw *fsnotify.Watcher, dir string) error {
+    return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+        if err != nil {
+            return err
+        }
+        if info.IsDir() {
+            return fsw.Add(path)
+        }
+        return nil
+    })
+}
+
+func (w *dirWatcher) handleEvent(fsw *fsnotify.Watcher, event fsnotify.Event) {
+    info, err := os.Stat(event.Name)
+    if err == nil && info.IsDir() {
+        if event.Op&fsnotify.Create != 0 {
+            if err := addRecursive(fsw, event.Name); err != nil {
+                log.Printf("watch: failed to watch new directory %s: %v", event.Name, err)
+            }
+        }
+        return
+    }
+
+    if !strings.EqualFold(filepath.Ext(event.Name), ".pdf") {
+        return
+    }
+    if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+        return
+    }
+
+    w.debounce(event.Name)
+}
+
+// debounce delays enqueuing a changed file until it's been quiet for
+// opts.debounce, so a large upload in progress isn't processed mid-write.
+func (w *dirWatcher) debounce(path string) {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+
+    if t, ok := w.pending[path]; ok {
+        t.Stop()
+    }
+    w.pending[path] = time.AfterFunc(w.opts.debounce, func() {
+        w.mu.Lock()
+        delete(w.pending, path)
+        w.mu.Unlock()
+        w.jobs <- path
+    })
+}
+
+func (w *dirWatcher) process(worker *PDFRedactor, inputPath string) {
+    rel, err := filepath.Rel(w.opts.inputDir, inputPath)
+    if err != nil {
+        rel = filepath.Base(inputPath)
+    }
+    outputPath := filepath.Join(w.opts.outputDir, rel)
+
+    entry := logEntry{
+        Timestamp: time.Now().UTC().Format(time.RFC3339),
+        File:      inputPath,
+        Output:    outputPath,
+    }
+
+    if text, extractErr := extractTextForStats(worker, inputPath); extractErr == nil {
+        entry.MatchesByRule = worker.CountMatchesPerRule(text)
+    }
+
+    if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+        entry.Error = err.Error()
+    } else if err := worker.RedactPDF(inputPath, outputPath); err != nil {
+        entry.Error = err.Error()
+    }
+
+    if line, err := json.Marshal(entry); err == nil {
+        fmt.Println(string(line))
+    }
+
+    if w.opts.moveProcessed == "" {
+        return
+    }
+    dest := filepath.Join(w.opts.moveProcessed, rel)
+    if entry.Error != "" {
+        dest = filepath.Join(w.opts.moveProcessed, "failed", rel)
+    }
+    w.relocate(inputPath, dest)
+}
+
+func (w *dirWatcher) relocate(src, dst string) {
+    if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+        log.Printf("watch: failed to prepare archive directory for %s: %v", src, err)
+        return
+    }
+    if err := os.Rename(src, dst); err != nil {
+        log.Printf("watch: failed to move %s to %s: %v", src, dst, err)
+    }
+}
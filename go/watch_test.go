@@ -0,0 +1,138 @@
+package main
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func testWatcher(t *testing.T, inputDir, outputDir, moveProcessed string) *dirWatcher {
+    t.Helper()
+    return newDirWatcher(watchOptions{
+        inputDir:      inputDir,
+        outputDir:     outputDir,
+        debounce:      20 * time.Millisecond,
+        moveProcessed: moveProcessed,
+    }, NewPDFRedactor())
+}
+
+func TestDebounce_RepeatedCallsResetTimerToOneJob(t *testing.T) {
+    w := testWatcher(t, t.TempDir(), t.TempDir(), "")
+
+    // Three rapid calls for the same path should collapse into a single
+    // enqueued job once the quiet period elapses, not one per call.
+    w.debounce("a.pdf")
+    w.debounce("a.pdf")
+    w.debounce("a.pdf")
+
+    select {
+    case path := <-w.jobs:
+        if path != "a.pdf" {
+            t.Fatalf("job path = %q, want a.pdf", path)
+        }
+    case <-time.After(time.Second):
+        t.Fatal("expected a.pdf to be enqueued after the debounce period")
+    }
+
+    select {
+    case path := <-w.jobs:
+        t.Fatalf("expected only one enqueued job, got a second: %q", path)
+    case <-time.After(100 * time.Millisecond):
+        // no second job, as expected
+    }
+}
+
+func TestDebounce_ClearsPendingEntryAfterFiring(t *testing.T) {
+    w := testWatcher(t, t.TempDir(), t.TempDir(), "")
+    w.debounce("a.pdf")
+
+    <-w.jobs
+
+    w.mu.Lock()
+    _, stillPending := w.pending["a.pdf"]
+    w.mu.Unlock()
+    if stillPending {
+        t.Fatal("expected pending entry to be cleared once the timer fires")
+    }
+}
+
+func TestProcess_MovesFailureToFailedSubdirUnderMoveProcessed(t *testing.T) {
+    inputDir := t.TempDir()
+    outputDir := t.TempDir()
+    archiveDir := t.TempDir()
+
+    rel := filepath.Join("sub", "bad.pdf")
+    inputPath := filepath.Join(inputDir, rel)
+    if err := os.MkdirAll(filepath.Dir(inputPath), 0o755); err != nil {
+        t.Fatalf("MkdirAll: %v", err)
+    }
+    if err := os.WriteFile(inputPath, []byte("not a real pdf"), 0o644); err != nil {
+        t.Fatalf("WriteFile: %v", err)
+    }
+
+    w := testWatcher(t, inputDir, outputDir, archiveDir)
+    w.process(w.base, inputPath)
+
+    wantDest := filepath.Join(archiveDir, "failed", rel)
+    if _, err := os.Stat(wantDest); err != nil {
+        t.Fatalf("expected failed input to be moved to %s: %v", wantDest, err)
+    }
+    if _, err := os.Stat(inputPath); !os.IsNotExist(err) {
+        t.Fatalf("expected input file to be moved out of %s", inputPath)
+    }
+}
+
+func TestProcess_OutputPathPreservesRelativeSubdir(t *testing.T) {
+    inputDir := t.TempDir()
+    outputDir := t.TempDir()
+
+    rel := filepath.Join("sub", "doc.pdf")
+    inputPath := filepath.Join(inputDir, rel)
+    if err := os.MkdirAll(filepath.Dir(inputPath), 0o755); err != nil {
+        t.Fatalf("MkdirAll: %v", err)
+    }
+    if err := os.WriteFile(inputPath, []byte("not a real pdf"), 0o644); err != nil {
+        t.Fatalf("WriteFile: %v", err)
+    }
+
+    w := testWatcher(t, inputDir, outputDir, "")
+    w.process(w.base, inputPath)
+
+    // RedactPDF fails on non-PDF content, but the output directory for the
+    // relative subpath must still be created up front.
+    wantDir := filepath.Join(outputDir, "sub")
+    if info, err := os.Stat(wantDir); err != nil || !info.IsDir() {
+        t.Fatalf("expected output subdirectory %s to exist: %v", wantDir, err)
+    }
+}
+
+func TestLogEntry_MarshalsExpectedJSONShape(t *testing.T) {
+    entry := logEntry{
+        Timestamp:     "2026-01-01T00:00:00Z",
+        File:          "in.pdf",
+        Output:        "out.pdf",
+        MatchesByRule: map[string]int{"ssn": 2},
+    }
+
+    b, err := json.Marshal(entry)
+    if err != nil {
+        t.Fatalf("json.Marshal: %v", err)
+    }
+
+    var decoded map[string]interface{}
+    if err := json.Unmarshal(b, &decoded); err != nil {
+        t.Fatalf("json.Unmarshal: %v", err)
+    }
+    if decoded["file"] != "in.pdf" || decoded["output"] != "out.pdf" {
+        t.Errorf("unexpected file/output fields: %+v", decoded)
+    }
+    if _, found := decoded["error"]; found {
+        t.Errorf("expected omitempty to drop a blank error field, got %+v", decoded)
+    }
+    rules, ok := decoded["matchesByRule"].(map[string]interface{})
+    if !ok || rules["ssn"] != float64(2) {
+        t.Errorf("expected matchesByRule.ssn = 2, got %+v", decoded["matchesByRule"])
+    }
+}